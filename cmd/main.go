@@ -1,19 +1,33 @@
 package main
 
 import (
+	"context"
 	_ "embed"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v7/estransport"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/moreirathomas/golastic/internal"
 	"github.com/moreirathomas/golastic/internal/http"
 	"github.com/moreirathomas/golastic/internal/repository"
 	"github.com/moreirathomas/golastic/pkg/dotenv"
+	"github.com/moreirathomas/golastic/pkg/embedding"
+	"github.com/moreirathomas/golastic/pkg/golastic"
+	"github.com/moreirathomas/golastic/pkg/golastic/golasticotel"
+	"github.com/moreirathomas/golastic/pkg/golastic/golasticprom"
 	"github.com/moreirathomas/golastic/pkg/logger"
 )
 
@@ -31,22 +45,115 @@ var env = map[string]string{
 //go:embed mapping.json
 var mapping string
 
+//go:embed search_books.mustache
+var searchTemplate string
+
 func main() {
 	envPath := flag.String("env-file", defaultEnvFile, "environment file path")
 	populate := flag.Bool("p", false, "Populated Elasticsearch with mockup data")
+	boostRecent := flag.Bool("boost-recent", false, "Rank recently created books higher in searches")
+	useTemplate := flag.Bool("search-template", false, "Perform full text searches with the stored search template, incompatible with -boost-recent")
+	embeddingDims := flag.Int("embedding-dims", 0, "Compute book embeddings of the given dimensions with the local hashing embedder")
+	trace := flag.Bool("trace", false, "Trace the requests down to Elasticsearch in "+filepath.Join(logPath, "traces.log"))
+	opts := serverOptions{}
+	flag.DurationVar(&opts.readTimeout, "read-timeout", http.DefaultReadTimeout, "Maximum duration for reading an entire request")
+	flag.DurationVar(&opts.writeTimeout, "write-timeout", http.DefaultWriteTimeout, "Maximum duration before timing out writes of a response")
+	flag.DurationVar(&opts.idleTimeout, "idle-timeout", http.DefaultIdleTimeout, "Maximum duration to wait for the next request on a keep-alive connection")
+	flag.IntVar(&opts.maxHeaderBytes, "max-header-bytes", http.DefaultMaxHeaderBytes, "Maximum size of request headers")
+	flag.DurationVar(&opts.shutdownTimeout, "shutdown-timeout", http.DefaultShutdownTimeout, "Maximum duration to wait for in-flight requests on shutdown")
 	flag.Parse()
 
 	if err := dotenv.Load(*envPath, env); err != nil {
 		log.Fatal(err)
 	}
 
-	if err := run(*populate); err != nil {
+	cfg := repository.Config{
+		IndexName:   env["ELASTICSEARCH_INDEX"],
+		Mapping:     mapping,
+		BoostRecent: *boostRecent,
+	}
+	if *useTemplate {
+		cfg.SearchTemplate = searchTemplate
+	}
+	if *embeddingDims > 0 {
+		embedder, err := embedding.NewHash(*embeddingDims)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.Embedder = embedder
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	cfg.Instrumentation = golasticprom.New(registry)
+
+	shutdownTracing := func() {}
+	var tracing golastic.Instrumentation
+	if *trace {
+		shutdown, err := initTracing()
+		if err != nil {
+			log.Fatal(err)
+		}
+		shutdownTracing = shutdown
+		tracing = golasticotel.New(nil)
+		cfg.Instrumentation = golastic.MultiInstrumentation(tracing, cfg.Instrumentation)
+	}
+
+	// The server is shut down gracefully on SIGINT or SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, cfg, registry, opts, *populate, tracing)
+
+	// Flush the remaining spans before closing the log files.
+	shutdownTracing()
+	if err := logger.Close(); err != nil {
+		log.Println(err)
+	}
+
+	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-func run(populate bool) error {
-	repo, err := initClient()
+// serverOptions holds the limits of the server set by CLI flags.
+type serverOptions struct {
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	maxHeaderBytes  int
+	shutdownTimeout time.Duration
+}
+
+// initTracing sets up the global tracer provider, exporting the spans
+// to a log file. The returned function flushes the remaining spans.
+func initTracing() (func(), error) {
+	exporter, err := stdouttrace.New(
+		stdouttrace.WithWriter(logger.DefaultFile(filepath.Join(logPath, "traces.log")).Writer()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the trace exporter: %s", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+	return func() {
+		if err := tp.Shutdown(context.Background()); err != nil {
+			log.Println(err)
+		}
+	}, nil
+}
+
+func run(ctx context.Context, cfg repository.Config, registry *prometheus.Registry, opts serverOptions, populate bool, tracing golastic.Instrumentation) error {
+	// Retries are performed by the resilient transport, which only
+	// retries idempotent requests and fails fast while the cluster is down.
+	// Requests carry the ID of the API call they belong to as X-Opaque-Id.
+	transport := golastic.NewResilientTransport(golastic.ResilienceConfig{
+		Retry:     golastic.RetryConfig{MaxRetries: golastic.DefaultMaxRetries},
+		Transport: golastic.OpaqueIDTransport{},
+	})
+
+	repo, err := initClient(cfg, transport, tracing)
 	if err != nil {
 		return err
 	}
@@ -61,28 +168,42 @@ func run(populate bool) error {
 	addr := ":" + env["SERVER_PORT"]
 	srv := http.NewServer(addr, *repo)
 	srv.ErrorLog = logger.DefaultFile(filepath.Join(logPath, "server.errorlog"))
-	return srv.Start()
+	srv.Registry = registry
+	srv.Transport = transport
+	srv.ReadTimeout = opts.readTimeout
+	srv.WriteTimeout = opts.writeTimeout
+	srv.IdleTimeout = opts.idleTimeout
+	srv.MaxHeaderBytes = opts.maxHeaderBytes
+	srv.ShutdownTimeout = opts.shutdownTimeout
+
+	// Requests in flight complete before Run returns, including their bulks.
+	return srv.Run(ctx)
 }
 
-func initClient() (*repository.Repository, error) {
+// initClient creates the Elasticsearch client and the repository using it.
+// The round trips are recorded as spans, children of their API call,
+// if tracing is not nil.
+func initClient(cfg repository.Config, transport *golastic.ResilientTransport, tracing golastic.Instrumentation) (*repository.Repository, error) {
+	var esLogger estransport.Logger = &estransport.TextLogger{
+		Output: logger.DefaultFile(filepath.Join(logPath, "elasticsearch.log")).Writer(),
+		// EnableRequestBody:  true,
+		// EnableResponseBody: true,
+	}
+	if tracing != nil {
+		esLogger = golastic.MultiLogger(esLogger, golastic.InstrumentationLogger{Instrumentation: tracing})
+	}
+
 	client, err := elasticsearch.NewClient(elasticsearch.Config{
-		Addresses: []string{env["ELASTICSEARCH_URL"]},
-		Logger: &estransport.TextLogger{
-			Output: logger.DefaultFile(filepath.Join(logPath, "elasticsearch.log")).Writer(),
-			// EnableRequestBody:  true,
-			// EnableResponseBody: true,
-		},
+		Addresses:    []string{env["ELASTICSEARCH_URL"]},
+		Transport:    transport,
+		DisableRetry: true,
+		Logger:       esLogger,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error creating Elasticsearch client: %s", err)
 	}
 
-	cfg := repository.Config{
-		Client:    client,
-		IndexName: env["ELASTICSEARCH_INDEX"],
-		Mapping:   mapping,
-	}
-
+	cfg.Client = client
 	repo, err := repository.New(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("error creating the repository: %s", err)
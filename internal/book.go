@@ -17,6 +17,17 @@ type Book struct {
 	Title     string    `json:"title"`
 	Abstract  string    `json:"abstract"`
 	Author    Author    `json:"author"`
+
+	// CoAuthors lists the authors of the book other than its main Author.
+	CoAuthors []Author `json:"co_authors,omitempty"`
+
+	// Branches lists the library branches stocking the book.
+	Branches []Branch `json:"branches,omitempty"`
+
+	// Embedding is the vector representation of the book computed by
+	// an Embedder, used to rank books by similarity. It is set by the
+	// repository and cannot be set by clients.
+	Embedding []float32 `json:"embedding,omitempty"`
 }
 
 // Author represents a book's author.
@@ -25,18 +36,122 @@ type Author struct {
 	Lastname  string `json:"lastname"`
 }
 
+// Branch represents a library branch stocking a book.
+type Branch struct {
+	Name     string   `json:"name"`
+	Location Location `json:"location"`
+}
+
+// Location represents a geographic location.
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Embedder computes vector representations of texts.
+// Texts with similar meanings are expected to have similar vectors.
+type Embedder interface {
+	// Embed returns the vector representation of the given text.
+	Embed(text string) ([]float32, error)
+
+	// Dims returns the dimensions of the computed vectors.
+	Dims() int
+}
+
+// EmbeddingText returns the text of the book represented by its embedding.
+func (b Book) EmbeddingText() string {
+	return b.Title + "\n" + b.Abstract
+}
+
+// BookQuery holds the parameters of a books search.
+type BookQuery struct {
+	Query string // Full text query. An empty query matches all books.
+	Size  int
+	From  int
+
+	// Fields restricts the returned fields of each book, e.g. "title" or
+	// "author.lastname". All fields are returned if empty.
+	Fields []string
+
+	// Author restricts the results to the books written or co-written
+	// by the author of the given full name, e.g. "Jane Doe".
+	Author string
+
+	// Near restricts the results to the books stocked in a branch within
+	// the Within distance of the given location, e.g. "5km". The books
+	// are then sorted by the distance of their closest branch.
+	Near   *Location
+	Within string
+
+	// Sort orders the results, criterion after criterion. The books are
+	// sorted by relevance if empty, or by distance if Near is set.
+	Sort []BookSort
+
+	// OnePerAuthor restricts the results to the best book of each author.
+	OnePerAuthor bool
+
+	// Explain and Profile enable the debugging information of BookResults.
+	Explain bool
+	Profile bool
+}
+
+// Sort criteria of a books search.
+const (
+	SortByCreatedAt = "created_at"
+	SortByTitle     = "title"
+	SortByAuthor    = "author"
+	SortByScore     = "score"
+	SortByDistance  = "distance" // Requires BookQuery.Near.
+)
+
+// BookSort is a sort criterion of a books search.
+type BookSort struct {
+	By   string // One of the SortBy constants.
+	Desc bool
+}
+
+// BookResults holds the books matching a search.
+type BookResults struct {
+	Books []Book
+
+	// Total is the number of matching books. It is a lower bound of
+	// the actual number if TotalIsLowerBound is true.
+	Total             int
+	TotalIsLowerBound bool
+
+	// Distances holds the distance in kilometers of the closest branch
+	// of each book by ID. It is only set if BookQuery.Near is set.
+	Distances map[string]float64
+
+	// Explanations details the score computation of each book by ID.
+	// It is only set if BookQuery.Explain is true.
+	Explanations map[string]*golastic.Explanation
+
+	// Profile is the raw profiling tree of the search.
+	// It is only set if BookQuery.Profile is true.
+	Profile json.RawMessage
+}
+
 // BookService gathers repository methods to perform CRUD on books.
 type BookService interface {
 
-	// SearchBooks retrieves all books matching the input query.
-	// It also returns the number of retrieved books.
-	SearchBooks(query string, size, from int) ([]Book, int, error)
+	// SearchBooks retrieves all books matching the input query
+	// along with the number of matching books.
+	SearchBooks(q BookQuery) (BookResults, error)
 
 	// GetBookByID retrieves a book by its ID in the repository.
 	// It returns a non-nil error if one occurs in the process
 	// or if no match were found.
 	GetBookByID(id string) (Book, error)
 
+	// SimilarBooks retrieves the books most similar to the book of
+	// the given ID, excluding this book.
+	SimilarBooks(id string, size int) ([]Book, error)
+
+	// RelatedBooks retrieves the books sharing the most terms with
+	// the book of the given ID, excluding this book.
+	RelatedBooks(id string, size int) ([]Book, error)
+
 	// InsertBook adds the given book in the repository.
 	// It returns the ID of the newly inserted book.
 	InsertBook(book Book) (string, error)
@@ -57,6 +172,16 @@ func (b Book) Validate(partial bool) error {
 		validation.Field(&b.Author, validation.By(func(_ interface{}) error {
 			return b.Author.Validate(partial)
 		})),
+		validation.Field(&b.Branches),
+		validation.Field(&b.CoAuthors, validation.By(func(_ interface{}) error {
+			for _, a := range b.CoAuthors {
+				if err := a.Validate(false); err != nil {
+					return err
+				}
+			}
+			return nil
+		})),
+		validation.Field(&b.Embedding, validation.Nil.Error("is computed by the server")),
 	)
 }
 
@@ -69,13 +194,26 @@ func (a Author) Validate(partial bool) error {
 	)
 }
 
-// UnmarshalHit returns a new hit for ElasticSearch search result that can be later
-// casted as a Book. It is necessary to implement elasticsearch.Unmarshaler interface.
-func (b Book) UnmarshalHit(h golastic.Hit) (interface{}, error) {
-	var bookResult Book
-	if err := json.Unmarshal(h.Source, &bookResult); err != nil {
-		return bookResult, err
-	}
-	bookResult.ID = h.ID
-	return bookResult, nil
+// Validate return a non-nil error if the branch receiver does not match
+// the validation requirements.
+func (b Branch) Validate() error {
+	return validation.ValidateStruct(&b,
+		validation.Field(&b.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&b.Location),
+	)
+}
+
+// Validate return a non-nil error if the location receiver is not
+// a valid geographic location.
+func (l Location) Validate() error {
+	return validation.ValidateStruct(&l,
+		validation.Field(&l.Lat, validation.Min(-90.0), validation.Max(90.0)),
+		validation.Field(&l.Lon, validation.Min(-180.0), validation.Max(180.0)),
+	)
+}
+
+// SetMetadata sets the ID of the book from its Elasticsearch hit.
+// It is necessary to implement golastic.MetadataSetter interface.
+func (b *Book) SetMetadata(h golastic.Hit) {
+	b.ID = h.ID
 }
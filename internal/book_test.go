@@ -39,4 +39,48 @@ func TestValidate(t *testing.T) {
 	if err := partial.Validate(true); err != nil {
 		t.Errorf("unexpected error: want nil, got %s", err)
 	}
+
+	partial.Embedding = []float32{1, 0}
+	if err := partial.Validate(true); err == nil {
+		t.Errorf("unexpected nil error for a client embedding")
+	}
+}
+
+func TestValidateCoAuthors(t *testing.T) {
+	b := internal.Book{
+		Title: "Good Omens",
+		Author: internal.Author{
+			Firstname: "Terry",
+			Lastname:  "Pratchett",
+		},
+		Abstract: "The world will end on Saturday.",
+		CoAuthors: []internal.Author{
+			{Firstname: "Neil", Lastname: "Gaiman"},
+		},
+	}
+	if err := b.Validate(false); err != nil {
+		t.Errorf("unexpected error: want nil, got %s", err)
+	}
+
+	b.CoAuthors = append(b.CoAuthors, internal.Author{Firstname: "Neil"})
+	if err := b.Validate(false); err == nil {
+		t.Errorf("unexpected nil error")
+	}
+}
+
+func TestValidateBranches(t *testing.T) {
+	b := internal.Book{
+		Title: "Good Omens",
+		Branches: []internal.Branch{
+			{Name: "Central", Location: internal.Location{Lat: 48.85, Lon: 2.35}},
+		},
+	}
+	if err := b.Validate(true); err != nil {
+		t.Errorf("unexpected error: want nil, got %s", err)
+	}
+
+	b.Branches = append(b.Branches, internal.Branch{Name: "Nowhere", Location: internal.Location{Lat: 91}})
+	if err := b.Validate(true); err == nil {
+		t.Errorf("unexpected nil error")
+	}
 }
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrValidation is returned when an entity does not match its
+// validation requirements.
+var ErrValidation = errors.New("validation error")
+
+// ValidationError wraps the error returned by the validation of an entity,
+// typically a validation.Errors holding the error of each invalid field.
+// It matches ErrValidation.
+type ValidationError struct {
+	Err error
+}
+
+// Error returns the error message.
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrValidation, e.Err)
+}
+
+// Is reports whether target is ErrValidation.
+func (e ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Unwrap returns the error of the validation.
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,8 @@
+//go:build production
+// +build production
+
+package http
+
+// debugEnabled allows clients to request search debugging information
+// through the "debug" query parameter. It is disabled in production builds.
+const debugEnabled = false
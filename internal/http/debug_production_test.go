@@ -0,0 +1,21 @@
+//go:build production
+// +build production
+
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtractQueryParamDebugProduction(t *testing.T) {
+	r := httptest.NewRequest("GET", "/books?debug=explain", nil)
+	if _, err := extractQueryParamDebug(r, "debug"); err == nil {
+		t.Error("expected debugging to be rejected in production builds")
+	}
+
+	r = httptest.NewRequest("GET", "/books", nil)
+	if _, err := extractQueryParamDebug(r, "debug"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,41 @@
+//go:build !production
+// +build !production
+
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtractQueryParamDebug(t *testing.T) {
+	testCases := []struct {
+		query   string
+		exp     debugOptions
+		wantErr bool
+	}{
+		{query: "", exp: debugOptions{}},
+		{query: "debug=explain", exp: debugOptions{Explain: true}},
+		{query: "debug=explain,%20profile", exp: debugOptions{Explain: true, Profile: true}},
+		{query: "debug=trace", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/books?"+tc.query, nil)
+			got, err := extractQueryParamDebug(r, "debug")
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.exp {
+				t.Errorf("unexpected options: expected %+v, got %+v", tc.exp, got)
+			}
+		})
+	}
+}
@@ -1,22 +1,64 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
+	"github.com/moreirathomas/golastic/internal"
+	"github.com/moreirathomas/golastic/internal/repository"
+	"github.com/moreirathomas/golastic/pkg/golastic"
 )
 
 var (
 	errBadRequest = httpError{nil, http.StatusText(http.StatusBadRequest), http.StatusBadRequest}
 	errNotFound   = httpError{nil, http.StatusText(http.StatusNotFound), http.StatusNotFound}
 	errInternal   = httpError{nil, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError}
+
+	errMethodNotAllowed = httpError{nil, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed}
+	errConflict         = httpError{nil, http.StatusText(http.StatusConflict), http.StatusConflict}
+	errUnprocessable    = httpError{nil, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity}
+	errUnavailable      = httpError{nil, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable}
 )
 
+// serverErrorDetail is the detail of the problems of status 5xx.
+const serverErrorDetail = "The request could not be processed. Please retry later."
+
+// errorMapping maps the errors of the domain, the repository and golastic
+// to the HTTP errors responded, in order of precedence. Missing resources
+// are reported by the repository: a golastic.ErrNotFound it does not
+// handle, e.g. a missing index, is not mapped.
+var errorMapping = []struct {
+	target  error
+	httpErr httpError
+}{
+	{internal.ErrValidation, errUnprocessable},
+	{repository.ErrResourceNotFound, errNotFound},
+	{repository.ErrNoEmbedding, errConflict},
+	{golastic.ErrConflict, errConflict},
+	{golastic.ErrBadRequest, errBadRequest},
+	{golastic.ErrUnavailable, errUnavailable},
+}
+
+// httpErrorFrom returns the HTTP error matching err, wrapping it.
+// Errors that are not mapped are wrapped by fallback.
+func httpErrorFrom(err error, fallback httpError) httpError {
+	for _, m := range errorMapping {
+		if errors.Is(err, m.target) {
+			return m.httpErr.Wrap(err)
+		}
+	}
+	return fallback.Wrap(err)
+}
+
 // httpError is a high-level error that wraps another error
-// and holds a HTTP status code.
+// and holds a HTTP status code. It is responded as a problem.
 type httpError struct {
-	wrapped error  // wrapped is used internally to share the error context.
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+	wrapped error // wrapped is used internally to share the error context.
+	Message string
+	Code    int
 }
 
 // Error returns an error's message.
@@ -37,3 +79,60 @@ func (e httpError) Wrap(target error) httpError {
 func (e httpError) Unwrap() error {
 	return e.wrapped
 }
+
+// problem is the body of an error response, as specified by RFC 7807.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Errors holds the error of each invalid field of the payload,
+	// by dot-separated field path, e.g. "author.firstname".
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// newProblem returns the problem describing e. The instance is the URI
+// of the request, if any.
+func newProblem(e httpError, r *http.Request) problem {
+	p := problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(e.Code),
+		Status: e.Code,
+	}
+	if r != nil {
+		p.Instance = r.URL.RequestURI()
+	}
+	if e.wrapped == nil {
+		return p
+	}
+
+	// The causes of internal errors are logged rather than exposed.
+	if e.Code >= http.StatusInternalServerError {
+		p.Detail = serverErrorDetail
+		return p
+	}
+	p.Detail = e.wrapped.Error()
+
+	var errs validation.Errors
+	if errors.As(e.wrapped, &errs) {
+		p.Errors = map[string]string{}
+		flattenErrors(p.Errors, errs, "")
+		p.Detail = internal.ErrValidation.Error()
+	}
+	return p
+}
+
+// flattenErrors copies the errors of the fields of errs to dst,
+// prefixing their name with prefix. Nested errors are flattened with
+// their dot-separated path.
+func flattenErrors(dst map[string]string, errs validation.Errors, prefix string) {
+	for field, err := range errs {
+		if nested, ok := err.(validation.Errors); ok { //nolint:errorlint // nested errors are not wrapped
+			flattenErrors(dst, nested, prefix+field+".")
+			continue
+		}
+		dst[prefix+field] = err.Error()
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/moreirathomas/golastic/internal/repository"
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestHTTPErrorFrom(t *testing.T) {
+	testCases := []struct {
+		err error
+		exp int
+	}{
+		{fmt.Errorf("%w: book 1", repository.ErrResourceNotFound), 404},
+		{fmt.Errorf("%w: 1", repository.ErrNoEmbedding), 409},
+		{fmt.Errorf("%w: no such index", golastic.ErrNotFound), 500},
+		{fmt.Errorf("%w: timeout", golastic.ErrUnavailable), 503},
+		{errors.New("unknown"), 500},
+	}
+
+	for _, tc := range testCases {
+		if got := httpErrorFrom(tc.err, errInternal); got.Code != tc.exp {
+			t.Errorf("unexpected status for %q: expected %d, got %d", tc.err, tc.exp, got.Code)
+		}
+	}
+}
+
+func TestRespondHTTPErrorHidesInternalErrors(t *testing.T) {
+	var logs bytes.Buffer
+	s := Server{Server: &http.Server{ErrorLog: log.New(&logs, "", 0)}}
+
+	w := httptest.NewRecorder()
+	s.respondHTTPError(w, httptest.NewRequest("GET", "/books", nil), errUnavailable.Wrap(errors.New("dial tcp 10.0.0.1:9200")))
+
+	var p problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != 503 || p.Detail != serverErrorDetail {
+		t.Errorf("unexpected problem: %+v", p)
+	}
+	if !strings.Contains(logs.String(), "GET /books: dial tcp 10.0.0.1:9200") {
+		t.Errorf("expected the error to be logged, got %q", logs.String())
+	}
+
+	logs.Reset()
+	w = httptest.NewRecorder()
+	s.respondHTTPError(w, httptest.NewRequest("GET", "/books/1", nil), errNotFound.Wrap(errors.New("book 1")))
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Detail != "book 1" {
+		t.Errorf("unexpected detail: %s", p.Detail)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("unexpected log: %q", logs.String())
+	}
+}
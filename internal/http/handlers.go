@@ -1,9 +1,13 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/moreirathomas/golastic/internal"
 	"github.com/moreirathomas/golastic/pkg/golastic"
 	"github.com/moreirathomas/golastic/pkg/pagination"
 )
@@ -25,28 +29,117 @@ func (s Server) SearchBooks(w http.ResponseWriter, r *http.Request) {
 	}
 	from := pagination.PageToOffset(page, size)
 
+	// Retrieve the author filter, if any
+	author := extractQueryParam(r, "author")
+
+	// Retrieve the location filter, if any
+	near, within, err := extractQueryParamNear(r, "near", "within")
+	if err != nil {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
+		return
+	}
+
+	// Retrieve the sort criteria, by relevance if omitted
+	sort, err := extractQueryParamSort(r, "sort")
+	if err != nil {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
+		return
+	}
+	for _, c := range sort {
+		if c.By == internal.SortByDistance && near == nil {
+			s.respondHTTPError(w, r, errBadRequest.Wrap(
+				errors.New("bad query parameter: \"sort\" by distance requires \"near\""),
+			))
+			return
+		}
+	}
+
+	// Retrieve the fields to return, all of them if omitted
+	fields, err := extractQueryParamFields(r, "fields")
+	if err != nil {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
+		return
+	}
+
+	// Retrieve the collapsing mode, if any
+	collapse := extractQueryParam(r, "collapse")
+	if collapse != "" && collapse != "author" {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(
+			fmt.Errorf("bad query parameter: \"collapse\" has invalid value \"%s\"", collapse),
+		))
+		return
+	}
+
+	// Retrieve the requested debugging information, if any
+	debug, err := extractQueryParamDebug(r, "debug")
+	if err != nil {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
+		return
+	}
+
 	// Perform ElasticSearch query
-	results, total, err := s.Repository.SearchBooks(q, size, from)
+	found, err := s.repository(r).SearchBooks(internal.BookQuery{
+		Query:        q,
+		Size:         size,
+		From:         from,
+		Fields:       fields,
+		Author:       author,
+		Near:         near,
+		Within:       within,
+		Sort:         sort,
+		OnePerAuthor: collapse == "author",
+		Explain:      debug.Explain,
+		Profile:      debug.Profile,
+	})
 	if err != nil {
-		respondHTTPError(w, errInternal.Wrap(err))
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
 		return
 	}
 
+	var results interface{} = found.Books
+	if len(fields) != 0 {
+		selected := make([]map[string]interface{}, 0, len(found.Books))
+		for _, b := range found.Books {
+			m, err := selectFields(b, fields)
+			if err != nil {
+				s.respondHTTPError(w, r, errInternal.Wrap(err))
+				return
+			}
+			selected = append(selected, m)
+		}
+		results = selected
+	}
+
 	// Paginate the results and send the response
-	p, err := pagination.New(r, total, page, size)
+	p, err := pagination.New(r, found.Total, page, size)
 	if err != nil {
-		respondHTTPError(w, errBadRequest.Wrap(err))
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
 		return
 	}
 
 	res := struct {
-		Results interface{} `json:"results"`
-		Total   int         `json:"total"`
+		Results           interface{}        `json:"results"`
+		Total             int                `json:"total"`
+		TotalIsLowerBound bool               `json:"total_is_lower_bound,omitempty"`
+		Distances         map[string]float64 `json:"distances,omitempty"`
+		Debug             interface{}        `json:"debug,omitempty"`
 		pagination.Pagination
 	}{
-		Results:    results,
-		Total:      total,
-		Pagination: p,
+		Results:           results,
+		Total:             found.Total,
+		TotalIsLowerBound: found.TotalIsLowerBound,
+		Distances:         found.Distances,
+		Pagination:        p,
+	}
+
+	if debug.Explain || debug.Profile {
+		res.Debug = struct {
+			Explanations map[string]*golastic.Explanation `json:"explanations,omitempty"`
+			Profile      json.RawMessage                  `json:"profile,omitempty"`
+		}{
+			Explanations: found.Explanations,
+			Profile:      found.Profile,
+		}
 	}
 
 	respondJSON(w, 200, res)
@@ -56,38 +149,88 @@ func (s Server) SearchBooks(w http.ResponseWriter, r *http.Request) {
 func (s Server) GetBookByID(w http.ResponseWriter, r *http.Request) {
 	id, err := extractRouteParam(r, "bookID")
 	if err != nil {
-		respondHTTPError(w, errBadRequest.Wrap(err))
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
 		return
 	}
 
-	book, err := s.Repository.GetBookByID(id)
+	book, err := s.repository(r).GetBookByID(id)
 	if err != nil {
-		respondHTTPError(w, errNotFound.Wrap(err))
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
 		return
 	}
 
 	respondJSON(w, 200, book)
 }
 
+// SimilarBooks retrieves the books most similar to a book by its ID.
+func (s Server) SimilarBooks(w http.ResponseWriter, r *http.Request) {
+	id, err := extractRouteParam(r, "bookID")
+	if err != nil {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
+		return
+	}
+
+	size, err := extractQueryParamInt(r, "size")
+	if err != nil || size < 1 {
+		size = golastic.DefaultQuerySize
+	}
+
+	books, err := s.repository(r).SimilarBooks(id, size)
+	if err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
+		return
+	}
+
+	respondJSON(w, 200, struct {
+		Results []internal.Book `json:"results"`
+	}{books})
+}
+
+// RelatedBooks retrieves the books related to a book by its ID,
+// based on the terms of their title and abstract.
+func (s Server) RelatedBooks(w http.ResponseWriter, r *http.Request) {
+	id, err := extractRouteParam(r, "bookID")
+	if err != nil {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
+		return
+	}
+
+	size, err := extractQueryParamInt(r, "size")
+	if err != nil || size < 1 {
+		size = golastic.DefaultQuerySize
+	}
+
+	books, err := s.repository(r).RelatedBooks(id, size)
+	if err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
+		return
+	}
+
+	respondJSON(w, 200, struct {
+		Results []internal.Book `json:"results"`
+	}{books})
+}
+
 // InsertBook adds a new book in the repository, if the request is valid.
 func (s Server) InsertBook(w http.ResponseWriter, r *http.Request) {
 	book, err := readBookPayload(r.Body)
 	if err != nil {
-		respondHTTPError(w, errBadRequest.Wrap(err))
+		s.respondHTTPError(w, r, httpErrorFrom(err, errBadRequest))
 		return
 	}
 
 	book.CreatedAt = time.Now()
-	id, err := s.Repository.InsertBook(book)
+	id, err := s.repository(r).InsertBook(book)
 	if err != nil {
-		// TODO: specify error handling (could be a duplicate or internal error)
-		respondHTTPError(w, errBadRequest.Wrap(err))
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
 		return
 	}
 
 	// Populate the book instance with the ID created on Elasticsearch part.
 	book.ID = id
 
+	s.matchSavedSearches(r, book)
+
 	respondJSON(w, 201, book)
 }
 
@@ -95,20 +238,19 @@ func (s Server) InsertBook(w http.ResponseWriter, r *http.Request) {
 func (s Server) UpdateBook(w http.ResponseWriter, r *http.Request) {
 	id, err := extractRouteParam(r, "bookID")
 	if err != nil {
-		respondHTTPError(w, errBadRequest.Wrap(err))
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
 		return
 	}
 
 	book, err := readBookPayload(r.Body)
 	if err != nil {
-		respondHTTPError(w, errBadRequest.Wrap(err))
+		s.respondHTTPError(w, r, httpErrorFrom(err, errBadRequest))
 		return
 	}
 	book.ID = id
 
-	if err := s.Repository.UpdateBook(book); err != nil {
-		// TODO: specify error handling (could be a duplicate or internal error)
-		respondHTTPError(w, errBadRequest.Wrap(err))
+	if err := s.repository(r).UpdateBook(book); err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
 		return
 	}
 
@@ -118,13 +260,13 @@ func (s Server) UpdateBook(w http.ResponseWriter, r *http.Request) {
 func (s Server) DeleteBook(w http.ResponseWriter, r *http.Request) {
 	id, err := extractRouteParam(r, "bookID")
 	if err != nil {
-		respondHTTPError(w, errBadRequest.Wrap(err))
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
 		return
 	}
 
-	if err := s.Repository.DeleteBook(id); err != nil {
-		// TODO: specify error handling (could be internal)
-		respondHTTPError(w, errNotFound.Wrap(err))
+	if err := s.repository(r).DeleteBook(id); err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
+		return
 	}
 
 	respondJSON(w, 204, nil)
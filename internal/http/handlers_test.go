@@ -0,0 +1,51 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/moreirathomas/golastic/internal/http"
+	"github.com/moreirathomas/golastic/internal/repository"
+)
+
+func TestInsertBookValidation(t *testing.T) {
+	srv := http.NewServer(":0", repository.Repository{})
+
+	body := `{"title": "Dune", "author": {"firstname": "Frank", "lastname": "H€rbert"}, "branches": [{"name": ""}]}`
+	r := httptest.NewRequest("POST", "/books?draft=1", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.InsertBook(w, r)
+
+	if w.Code != 422 {
+		t.Errorf("unexpected status: expected 422, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+
+	var got struct {
+		Type     string            `json:"type"`
+		Title    string            `json:"title"`
+		Status   int               `json:"status"`
+		Instance string            `json:"instance"`
+		Errors   map[string]string `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Type != "about:blank" || got.Title != "Unprocessable Entity" || got.Status != 422 || got.Instance != "/books?draft=1" {
+		t.Errorf("unexpected problem: %+v", got)
+	}
+	exp := []string{"author.lastname", "branches.0.name"}
+	if len(got.Errors) != len(exp) {
+		t.Errorf("unexpected field errors: expected %v, got %v", exp, got.Errors)
+	}
+	for _, f := range exp {
+		if got.Errors[f] == "" {
+			t.Errorf("missing error of field %s, got %v", f, got.Errors)
+		}
+	}
+}
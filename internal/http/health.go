@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/moreirathomas/golastic/internal/repository"
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// Liveness reports that the process is up. It does not depend on
+// Elasticsearch, so that an unreachable cluster does not restart the server.
+func (s Server) Liveness(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readiness is the body of the readiness check.
+type readiness struct {
+	Ready bool `json:"ready"`
+
+	// Breaker is the state of the circuit breaker of the client, if known.
+	Breaker string `json:"breaker,omitempty"`
+
+	*repository.Health
+}
+
+// Readiness reports whether the server can serve requests: the circuit
+// breaker of the client is not open, Elasticsearch is reachable, the books
+// index exists and the cluster status is not red.
+func (s Server) Readiness(w http.ResponseWriter, r *http.Request) {
+	var body readiness
+	if s.Transport != nil {
+		state := s.Transport.BreakerState()
+		body.Breaker = state.String()
+		// Requests fail fast while the breaker is open: the health
+		// of Elasticsearch cannot be checked.
+		if state == golastic.BreakerOpen {
+			respondJSON(w, http.StatusServiceUnavailable, body)
+			return
+		}
+	}
+
+	health, err := s.repository(r).Health()
+	if err != nil {
+		s.respondHTTPError(w, r, errUnavailable.Wrap(err))
+		return
+	}
+	body.Ready = health.Ready()
+	body.Health = &health
+
+	code := http.StatusOK
+	if !body.Ready {
+		code = http.StatusServiceUnavailable
+	}
+	respondJSON(w, code, body)
+}
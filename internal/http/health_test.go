@@ -0,0 +1,90 @@
+package http_test
+
+import (
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/moreirathomas/golastic/internal/http"
+	"github.com/moreirathomas/golastic/internal/repository"
+	"github.com/moreirathomas/golastic/pkg/golastic"
+	"github.com/moreirathomas/golastic/pkg/golastic/golastictest"
+)
+
+// downTransport fails all round trips while down is set.
+type downTransport struct {
+	down int32
+}
+
+func (t *downTransport) RoundTrip(r *nethttp.Request) (*nethttp.Response, error) {
+	if atomic.LoadInt32(&t.down) == 1 {
+		return nil, errors.New("connection refused")
+	}
+	return nethttp.DefaultTransport.RoundTrip(r)
+}
+
+func TestReadinessBreaker(t *testing.T) {
+	mapping, err := os.ReadFile("../../cmd/mapping.json")
+	if err != nil {
+		t.Fatalf("cannot read mapping: %s", err)
+	}
+
+	es := golastictest.NewServer()
+	defer es.Close()
+
+	down := &downTransport{}
+	transport := golastic.NewResilientTransport(golastic.ResilienceConfig{
+		Transport: down,
+		Breaker:   golastic.BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour},
+	})
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses:    []string{es.URL},
+		Transport:    transport,
+		DisableRetry: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	repo, err := repository.New(repository.Config{
+		Client:    client,
+		IndexName: "books",
+		Mapping:   string(mapping),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	srv := http.NewServer(":0", *repo)
+	srv.Transport = transport
+
+	readiness := func() (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		srv.Readiness(w, httptest.NewRequest("GET", "/readyz", nil))
+		var body map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, body
+	}
+
+	if code, body := readiness(); code != 200 || body["ready"] != true || body["breaker"] != "closed" {
+		t.Errorf("unexpected readiness: %d %v", code, body)
+	}
+
+	// The failing health check opens the breaker.
+	atomic.StoreInt32(&down.down, 1)
+	if code, _ := readiness(); code != 503 {
+		t.Errorf("unexpected status: expected 503, got %d", code)
+	}
+
+	if code, body := readiness(); code != 503 || body["ready"] != false || body["breaker"] != "open" {
+		t.Errorf("unexpected readiness: %d %v", code, body)
+	}
+}
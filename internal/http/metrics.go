@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/moreirathomas/golastic/pkg/logger"
+)
+
+// metrics holds the Prometheus metrics of the server.
+type metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+
+	// route returns the route label of a request.
+	route func(r *http.Request) string
+}
+
+// newMetrics returns the metrics of the server registered with reg.
+// The requests are labelled with the route returned by route.
+func newMetrics(reg prometheus.Registerer, route func(r *http.Request) string) metrics {
+	labels := []string{"method", "route", "status"}
+	m := metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by route and status.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of the HTTP requests served, by route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests being served.",
+		}),
+		route: route,
+	}
+	reg.MustRegister(m.requests, m.duration, m.inFlight)
+	return m
+}
+
+// middleware records the metrics of the requests served by h.
+func (m metrics) middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		start := time.Now()
+		rw := logger.NewResponseWriter(w)
+		h.ServeHTTP(rw, r)
+
+		labels := prometheus.Labels{
+			"method": r.Method,
+			"route":  m.route(r),
+			"status": strconv.Itoa(rw.Status()),
+		}
+		m.requests.With(labels).Inc()
+		m.duration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// metricsHandler serves the metrics gathered by g.
+func metricsHandler(g prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/moreirathomas/golastic/internal/repository"
+)
+
+func TestMiddlewaresCoverUnmatchedRequests(t *testing.T) {
+	s := NewServer(":0", repository.Repository{})
+	s.initRouter()
+
+	testCases := []struct {
+		method, path string
+		route        string
+		status       string
+	}{
+		{http.MethodGet, "/healthz", "/healthz", "200"},
+		{http.MethodGet, "/nope", unmatchedRoute, "404"},
+		{http.MethodDelete, "/healthz", unmatchedRoute, "405"},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		s.Handler.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if w.Header().Get("X-Request-ID") == "" {
+			t.Errorf("%s %s: missing request ID", tc.method, tc.path)
+		}
+		if n := requestCount(t, s.Registry, tc.method, tc.route, tc.status); n != 1 {
+			t.Errorf("%s %s: unexpected request count: expected 1, got %v", tc.method, tc.path, n)
+		}
+	}
+}
+
+// requestCount returns the count of the requests of the given labels
+// gathered from g.
+func requestCount(t *testing.T, g prometheus.Gatherer, method, route, status string) float64 {
+	t.Helper()
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]string{"method": method, "route": route, "status": status}
+	for _, f := range families {
+		if f.GetName() != "http_requests_total" {
+			continue
+		}
+	metrics:
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if exp[l.GetName()] != l.GetValue() {
+					continue metrics
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 
@@ -28,6 +30,159 @@ func extractQueryParamInt(r *http.Request, p string) (int, error) {
 	return q, nil
 }
 
+// fieldPattern matches a dot-separated field path such as "author.lastname".
+var fieldPattern = regexp.MustCompile(`^[a-zA-Z_]+(\.[a-zA-Z_]+)*$`)
+
+// extractQueryParamFields returns the comma-separated field paths of the given
+// param in the request query. It returns a non nil error if a field path
+// is malformed.
+func extractQueryParamFields(r *http.Request, p string) ([]string, error) {
+	qStr := extractQueryParam(r, p)
+	if qStr == "" {
+		return nil, nil
+	}
+
+	fields := []string{}
+	for _, f := range strings.Split(qStr, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !fieldPattern.MatchString(f) {
+			return nil, fmt.Errorf("bad query parameter: \"%s\" has invalid field \"%s\"", p, f)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// sortCriteria are the values accepted by the sort query parameter.
+var sortCriteria = map[string]bool{
+	internal.SortByCreatedAt: true,
+	internal.SortByTitle:     true,
+	internal.SortByAuthor:    true,
+	internal.SortByScore:     true,
+	internal.SortByDistance:  true,
+}
+
+// extractQueryParamSort returns the sort criteria of the given param
+// in the request query (see parseSort).
+func extractQueryParamSort(r *http.Request, p string) ([]internal.BookSort, error) {
+	criteria, err := parseSort(extractQueryParam(r, p))
+	if err != nil {
+		return nil, fmt.Errorf("bad query parameter: \"%s\" %s", p, err)
+	}
+	return criteria, nil
+}
+
+// parseSort returns the comma-separated sort criteria of s, such as
+// "-created_at,title". Criteria prefixed with "-" are in descending order,
+// except the score: it is in descending order by default, so that the
+// best matches come first, and in ascending order if prefixed with "-".
+// It returns a non nil error if a criterion is unknown or repeated.
+func parseSort(s string) ([]internal.BookSort, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	criteria := []internal.BookSort{}
+	seen := map[string]bool{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		c := internal.BookSort{By: strings.TrimPrefix(v, "-"), Desc: strings.HasPrefix(v, "-")}
+		if c.By == internal.SortByScore {
+			c.Desc = !c.Desc
+		}
+		if !sortCriteria[c.By] {
+			return nil, fmt.Errorf("has invalid criterion \"%s\"", v)
+		}
+		if seen[c.By] {
+			return nil, fmt.Errorf("has repeated criterion \"%s\"", c.By)
+		}
+		seen[c.By] = true
+		criteria = append(criteria, c)
+	}
+	return criteria, nil
+}
+
+// defaultWithin is the distance of the location filter
+// if the client does not provide one.
+const defaultWithin = "10km"
+
+// distancePattern matches a distance such as "5km" or "500m".
+var distancePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(km|m|mi)$`)
+
+// extractQueryParamNear returns the location of the given param in the
+// request query, formatted as "lat,lon", and the distance of the within
+// param, defaulting to defaultWithin. The location is nil if the param is
+// omitted. It returns a non nil error if the location or the distance
+// is malformed.
+func extractQueryParamNear(r *http.Request, p, within string) (*internal.Location, string, error) {
+	qStr := extractQueryParam(r, p)
+	if qStr == "" {
+		return nil, "", nil
+	}
+
+	coords := strings.Split(qStr, ",")
+	if len(coords) != 2 {
+		return nil, "", fmt.Errorf("bad query parameter: \"%s\" must be formatted as \"lat,lon\"", p)
+	}
+	var loc internal.Location
+	var errLat, errLon error
+	loc.Lat, errLat = strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
+	loc.Lon, errLon = strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
+	if errLat != nil || errLon != nil {
+		return nil, "", fmt.Errorf("bad query parameter: \"%s\" must be formatted as \"lat,lon\"", p)
+	}
+	if err := loc.Validate(); err != nil {
+		return nil, "", fmt.Errorf("bad query parameter: \"%s\" is invalid: %s", p, err)
+	}
+
+	d := extractQueryParam(r, within)
+	if d == "" {
+		d = defaultWithin
+	}
+	if !distancePattern.MatchString(d) {
+		return nil, "", fmt.Errorf("bad query parameter: \"%s\" has invalid distance \"%s\"", within, d)
+	}
+
+	return &loc, d, nil
+}
+
+// debugOptions holds the debugging information requested by a client.
+type debugOptions struct {
+	Explain bool
+	Profile bool
+}
+
+// extractQueryParamDebug returns the debugging information requested by the
+// comma-separated values of the given param, among "explain" and "profile".
+// It returns a non nil error if a value is unknown or if debugging is
+// disabled in the current build.
+func extractQueryParamDebug(r *http.Request, p string) (debugOptions, error) {
+	opts := debugOptions{}
+	qStr := extractQueryParam(r, p)
+	if qStr == "" {
+		return opts, nil
+	}
+
+	if !debugEnabled {
+		return opts, fmt.Errorf("bad query parameter: \"%s\" is not available", p)
+	}
+
+	for _, v := range strings.Split(qStr, ",") {
+		switch strings.TrimSpace(v) {
+		case "explain":
+			opts.Explain = true
+		case "profile":
+			opts.Profile = true
+		default:
+			return opts, fmt.Errorf("bad query parameter: \"%s\" has invalid value \"%s\"", p, v)
+		}
+	}
+	return opts, nil
+}
+
 // extractRouteParam retreives the given route parameter from the
 // mux path variables.
 func extractRouteParam(r *http.Request, p string) (string, error) {
@@ -55,7 +210,7 @@ func readBookPayload(body io.ReadCloser) (internal.Book, error) {
 	}
 
 	if err := book.Validate(true); err != nil {
-		return internal.Book{}, err
+		return internal.Book{}, internal.ValidationError{Err: err}
 	}
 
 	return book, nil
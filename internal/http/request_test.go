@@ -0,0 +1,39 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/moreirathomas/golastic/internal"
+)
+
+func TestParseSort(t *testing.T) {
+	testCases := []struct {
+		sort string
+		exp  []internal.BookSort
+	}{
+		{"-created_at, title", []internal.BookSort{
+			{By: internal.SortByCreatedAt, Desc: true},
+			{By: internal.SortByTitle},
+		}},
+		// The best matches come first by default.
+		{"score", []internal.BookSort{{By: internal.SortByScore, Desc: true}}},
+		{"-score", []internal.BookSort{{By: internal.SortByScore}}},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseSort(tc.sort)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", tc.sort, err)
+		}
+		if !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("unexpected sort for %q: expected %v, got %v", tc.sort, tc.exp, got)
+		}
+	}
+
+	for _, s := range []string{"abstract", "title,-title", "--score", "title,"} {
+		if _, err := parseSort(s); err == nil {
+			t.Errorf("unexpected nil error for %q", s)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
 // setHeader is a helper function for writing response header' content type and code.
@@ -13,21 +14,76 @@ func setHeader(w http.ResponseWriter, code int) {
 
 // respondJSON sends the given data as JSON. The response status code is set to the given code.
 func respondJSON(w http.ResponseWriter, code int, data interface{}) {
-	setHeader(w, code)
-
 	resp, err := json.Marshal(data)
 	if err != nil {
-		respondHTTPError(w, errInternal)
+		writeProblem(w, nil, errInternal)
 		return
 	}
+
+	setHeader(w, code)
 	w.Write(resp)
 }
 
-// respondHTTPError formats the given error and sends it as JSON.
-// Parameter `httpErr` sets the status code.
-func respondHTTPError(w http.ResponseWriter, httpErr httpError) {
-	resp := map[string]httpError{
-		"error": httpErr,
+// respondHTTPError sends the given error as a problem in JSON (RFC 7807).
+// Parameter `httpErr` sets the status code and r the instance.
+// The internal errors are logged, as they are not detailed in the problem.
+func (s Server) respondHTTPError(w http.ResponseWriter, r *http.Request, httpErr httpError) {
+	if httpErr.Code >= http.StatusInternalServerError && httpErr.wrapped != nil {
+		s.logf(r, "%s %s: %s", r.Method, r.URL.RequestURI(), httpErr.wrapped)
+	}
+	writeProblem(w, r, httpErr)
+}
+
+// writeProblem writes the given error as a problem in JSON (RFC 7807).
+// Parameter `httpErr` sets the status code and r, if not nil, the instance.
+func writeProblem(w http.ResponseWriter, r *http.Request, httpErr httpError) {
+	resp, _ := json.Marshal(newProblem(httpErr, r)) // A problem always marshals.
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(httpErr.Code)
+	w.Write(resp)
+}
+
+// selectFields returns the JSON representation of v restricted to the given
+// dot-separated field paths. The "id" field is always kept.
+func selectFields(v interface{}, fields []string) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var src map[string]interface{}
+	if err := json.Unmarshal(b, &src); err != nil {
+		return nil, err
+	}
+
+	dst := map[string]interface{}{}
+	for _, f := range append([]string{"id"}, fields...) {
+		copyPath(dst, src, strings.Split(f, "."))
+	}
+	return dst, nil
+}
+
+// copyPath copies the value at path from src to dst, creating
+// the intermediate objects in dst if necessary.
+func copyPath(dst, src map[string]interface{}, path []string) {
+	v, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = v
+		return
+	}
+
+	child, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	sub, ok := dst[path[0]].(map[string]interface{})
+	if !ok {
+		sub = map[string]interface{}{}
+		dst[path[0]] = sub
 	}
-	respondJSON(w, httpErr.Code, resp)
+	copyPath(sub, child, path[1:])
 }
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/moreirathomas/golastic/internal"
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// InsertReview adds a new review of a book by its ID in the repository,
+// if the request is valid.
+func (s Server) InsertReview(w http.ResponseWriter, r *http.Request) {
+	bookID, err := extractRouteParam(r, "bookID")
+	if err != nil {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
+		return
+	}
+
+	review, err := readReviewPayload(r.Body)
+	if err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errBadRequest))
+		return
+	}
+
+	review.BookID = bookID
+	review.CreatedAt = time.Now()
+	id, err := s.repository(r).InsertReview(review)
+	if err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
+		return
+	}
+
+	// Populate the review instance with the ID created on Elasticsearch part.
+	review.ID = id
+
+	respondJSON(w, 201, review)
+}
+
+// BookReviews retrieves the most recent reviews of a book by its ID,
+// along with its average rating.
+func (s Server) BookReviews(w http.ResponseWriter, r *http.Request) {
+	bookID, err := extractRouteParam(r, "bookID")
+	if err != nil {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
+		return
+	}
+
+	size, err := extractQueryParamInt(r, "size")
+	if err != nil || size < 1 {
+		size = golastic.DefaultQuerySize
+	}
+
+	found, err := s.repository(r).BookReviews(bookID, size)
+	if err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
+		return
+	}
+
+	respondJSON(w, 200, struct {
+		Results       []internal.Review `json:"results"`
+		Total         int               `json:"total"`
+		AverageRating *float64          `json:"average_rating"`
+	}{found.Reviews, found.Total, found.AverageRating})
+}
+
+func readReviewPayload(body io.ReadCloser) (internal.Review, error) {
+	var review internal.Review
+
+	if err := decodeBody(body, &review); err != nil {
+		return internal.Review{}, err
+	}
+
+	if err := review.Validate(); err != nil {
+		return internal.Review{}, internal.ValidationError{Err: err}
+	}
+
+	return review, nil
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/moreirathomas/golastic/internal"
+)
+
+// InsertSavedSearch saves a new search in the repository, if the request
+// is valid. The books inserted afterwards are recorded in the saved search
+// if they match its query.
+func (s Server) InsertSavedSearch(w http.ResponseWriter, r *http.Request) {
+	search, err := readSavedSearchPayload(r.Body)
+	if err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errBadRequest))
+		return
+	}
+
+	search.CreatedAt = time.Now()
+	search.BookIDs = []string{}
+	id, err := s.repository(r).InsertSavedSearch(search)
+	if err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
+		return
+	}
+
+	// Populate the saved search instance with the ID created on Elasticsearch part.
+	search.ID = id
+
+	respondJSON(w, 201, search)
+}
+
+// GetSavedSearchByID retrieves a saved search by its ID in the repository.
+func (s Server) GetSavedSearchByID(w http.ResponseWriter, r *http.Request) {
+	id, err := extractRouteParam(r, "savedSearchID")
+	if err != nil {
+		s.respondHTTPError(w, r, errBadRequest.Wrap(err))
+		return
+	}
+
+	search, err := s.repository(r).GetSavedSearchByID(id)
+	if err != nil {
+		s.respondHTTPError(w, r, httpErrorFrom(err, errInternal))
+		return
+	}
+
+	respondJSON(w, 200, search)
+}
+
+// matchSavedSearches records the given book in the saved searches
+// it matches. Failures are logged as the book is already inserted.
+func (s Server) matchSavedSearches(r *http.Request, book internal.Book) {
+	if _, err := s.repository(r).MatchSavedSearches(book); err != nil {
+		s.logf(r, "failed to match saved searches for book %s: %s", book.ID, err)
+	}
+}
+
+func readSavedSearchPayload(body io.ReadCloser) (internal.SavedSearch, error) {
+	var search internal.SavedSearch
+
+	if err := decodeBody(body, &search); err != nil {
+		return internal.SavedSearch{}, err
+	}
+
+	if err := search.Validate(); err != nil {
+		return internal.SavedSearch{}, internal.ValidationError{Err: err}
+	}
+
+	return search, nil
+}
@@ -0,0 +1,67 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/moreirathomas/golastic/internal"
+	"github.com/moreirathomas/golastic/internal/http"
+	"github.com/moreirathomas/golastic/internal/repository"
+	"github.com/moreirathomas/golastic/pkg/golastic/golastictest"
+)
+
+func TestSavedSearchRecordsInsertedBooks(t *testing.T) {
+	mapping, err := os.ReadFile("../../cmd/mapping.json")
+	if err != nil {
+		t.Fatalf("cannot read mapping: %s", err)
+	}
+	repo, err := repository.New(repository.Config{
+		Client:    golastictest.NewClient(t),
+		IndexName: "books",
+		Mapping:   string(mapping),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	srv := http.NewServer(":0", *repo)
+
+	w := httptest.NewRecorder()
+	srv.InsertSavedSearch(w, httptest.NewRequest("POST", "/saved-searches", strings.NewReader(`{"query": "desert planet"}`)))
+	if w.Code != 201 {
+		t.Fatalf("unexpected status: expected 201, got %d: %s", w.Code, w.Body)
+	}
+	var search internal.SavedSearch
+	if err := json.NewDecoder(w.Body).Decode(&search); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"title": "Dune", "abstract": "A desert planet.", "author": {"firstname": "Frank", "lastname": "Herbert"}}`
+	w = httptest.NewRecorder()
+	srv.InsertBook(w, httptest.NewRequest("POST", "/books", strings.NewReader(body)))
+	if w.Code != 201 {
+		t.Fatalf("unexpected status: expected 201, got %d: %s", w.Code, w.Body)
+	}
+	var book internal.Book
+	if err := json.NewDecoder(w.Body).Decode(&book); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/saved-searches/"+search.ID, nil)
+	r = mux.SetURLVars(r, map[string]string{"savedSearchID": search.ID})
+	w = httptest.NewRecorder()
+	srv.GetSavedSearchByID(w, r)
+	if w.Code != 200 {
+		t.Fatalf("unexpected status: expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if err := json.NewDecoder(w.Body).Decode(&search); err != nil {
+		t.Fatal(err)
+	}
+	if len(search.BookIDs) != 1 || search.BookIDs[0] != book.ID {
+		t.Errorf("unexpected book IDs: expected [%s], got %v", book.ID, search.BookIDs)
+	}
+}
@@ -1,27 +1,70 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 
 	"github.com/moreirathomas/golastic/internal/repository"
+	"github.com/moreirathomas/golastic/pkg/golastic"
 	"github.com/moreirathomas/golastic/pkg/logger"
+	"github.com/moreirathomas/golastic/pkg/requestid"
 )
 
+// serviceName identifies the server in traces.
+const serviceName = "golastic"
+
+// Default limits of the server, overridden by setting the fields
+// of the embedded http.Server.
+const (
+	DefaultReadTimeout    = 5 * time.Second
+	DefaultWriteTimeout   = 10 * time.Second
+	DefaultIdleTimeout    = 120 * time.Second
+	DefaultMaxHeaderBytes = 1 << 20
+)
+
+// DefaultShutdownTimeout is the default deadline for in-flight requests
+// to complete once the server is shutting down.
+const DefaultShutdownTimeout = 10 * time.Second
+
 // Server represents the main server for the API.
 type Server struct {
 	*http.Server
 	router     *mux.Router
 	Repository repository.Repository
+
+	// Registry holds the metrics served at /metrics, along with the metrics
+	// of the server. A new registry is used if nil.
+	Registry *prometheus.Registry
+
+	// Transport is the transport of the Elasticsearch client. The state of
+	// its circuit breaker is reported by the readiness check if not nil.
+	Transport *golastic.ResilientTransport
+
+	// ShutdownTimeout is the deadline for in-flight requests to complete
+	// once Run is shutting down the server.
+	ShutdownTimeout time.Duration
 }
 
 // NewServer returns a new Server given configuration parameters.
 func NewServer(addr string, repo repository.Repository) *Server {
 	return &Server{
-		Server:     &http.Server{Addr: addr},
-		Repository: repo,
+		Server: &http.Server{
+			Addr:           addr,
+			ReadTimeout:    DefaultReadTimeout,
+			WriteTimeout:   DefaultWriteTimeout,
+			IdleTimeout:    DefaultIdleTimeout,
+			MaxHeaderBytes: DefaultMaxHeaderBytes,
+		},
+		Repository:      repo,
+		ShutdownTimeout: DefaultShutdownTimeout,
 	}
 }
 
@@ -29,26 +72,100 @@ func NewServer(addr string, repo repository.Repository) *Server {
 // It serves its attached router at its Addr.
 func (s *Server) Start() error {
 	s.initRouter()
-	s.Handler = s.router
 
 	log.Printf("Server listening at http://localhost%s\n", s.Addr)
 
 	return s.ListenAndServe()
 }
 
+// Run starts the server and shuts it down gracefully once ctx is done:
+// it stops accepting connections and waits for the in-flight requests
+// to complete, up to ShutdownTimeout. It returns nil once shut down.
+func (s *Server) Run(ctx context.Context) error {
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Server shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("error shutting down the server: %s", err)
+	}
+
+	// Start returns as soon as Shutdown is called.
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// initRouter registers the routes and sets the handler of the server:
+// the router wrapped by the middlewares, so that they also apply to the
+// requests matching no route.
 func (s *Server) initRouter() {
 	s.router = mux.NewRouter().StrictSlash(true)
-	s.router.Use(logger.RequestLogger)
+	s.router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.respondHTTPError(w, r, errNotFound)
+	})
+	s.router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.respondHTTPError(w, r, errMethodNotAllowed)
+	})
+	if s.Registry == nil {
+		s.Registry = prometheus.NewRegistry()
+	}
 	s.registerRoutes()
+
+	traces := otelmux.Middleware(serviceName, otelmux.WithSpanNameFormatter(
+		func(_ string, r *http.Request) string { return s.routeTemplate(r) },
+	))
+	s.Handler = requestid.Middleware(
+		traces(newMetrics(s.Registry, s.routeTemplate).middleware(
+			logger.RequestLogger(s.router),
+		)),
+	)
+}
+
+// unmatchedRoute labels the requests matching no route, so that
+// arbitrary paths do not each get their own label.
+const unmatchedRoute = "unmatched"
+
+// routeTemplate returns the path template of the route matching r,
+// e.g. "/books/{bookID}", or unmatchedRoute if none matches.
+func (s *Server) routeTemplate(r *http.Request) string {
+	var match mux.RouteMatch
+	if !s.router.Match(r, &match) || match.MatchErr != nil || match.Route == nil {
+		return unmatchedRoute
+	}
+	tpl, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+	return tpl
 }
 
 // registerRoutes registers each entity's routes on the server.
 func (s *Server) registerRoutes() {
-	const bookID = "{bookID:[a-zA-Z0-9_-]+}"
+	const (
+		bookID        = "{bookID:[a-zA-Z0-9_-]+}"
+		savedSearchID = "{savedSearchID:[a-zA-Z0-9_-]+}"
+	)
 
 	// Root
 	s.router.HandleFunc("/", s.handleIndex)
 
+	// Liveness and readiness probes
+	s.router.HandleFunc("/healthz", s.Liveness).Methods(http.MethodGet)
+	s.router.HandleFunc("/readyz", s.Readiness).Methods(http.MethodGet)
+
+	// Metrics
+	s.router.Handle("/metrics", metricsHandler(s.Registry)).Methods(http.MethodGet)
+
 	// Insert new book
 	s.router.HandleFunc("/books", s.InsertBook).Methods(http.MethodPost)
 
@@ -58,11 +175,50 @@ func (s *Server) registerRoutes() {
 	// Get book by ID
 	s.router.HandleFunc("/books/"+bookID, s.GetBookByID).Methods(http.MethodGet)
 
+	// Get books similar to a book by ID
+	s.router.HandleFunc("/books/"+bookID+"/similar", s.SimilarBooks).Methods(http.MethodGet)
+
+	// Get books related to a book by ID
+	s.router.HandleFunc("/books/"+bookID+"/related", s.RelatedBooks).Methods(http.MethodGet)
+
+	// Insert new review of a book by ID
+	s.router.HandleFunc("/books/"+bookID+"/reviews", s.InsertReview).Methods(http.MethodPost)
+
+	// Get reviews of a book by ID
+	s.router.HandleFunc("/books/"+bookID+"/reviews", s.BookReviews).Methods(http.MethodGet)
+
 	// Update book
 	s.router.HandleFunc("/books/"+bookID, s.UpdateBook).Methods(http.MethodPut)
 
 	// Delete book by ID
 	s.router.HandleFunc("/books/"+bookID, s.DeleteBook).Methods(http.MethodDelete)
+
+	// Insert new saved search
+	s.router.HandleFunc("/saved-searches", s.InsertSavedSearch).Methods(http.MethodPost)
+
+	// Get saved search by ID
+	s.router.HandleFunc("/saved-searches/"+savedSearchID, s.GetSavedSearchByID).Methods(http.MethodGet)
+}
+
+// repository returns the repository sending its requests to Elasticsearch
+// with the context of the given request, so that they can be traced.
+// The requests carry the ID of the request as opaque ID.
+func (s Server) repository(r *http.Request) repository.Repository {
+	ctx := r.Context()
+	return s.Repository.WithContext(golastic.WithOpaqueID(ctx, requestid.FromContext(ctx)))
+}
+
+// logf logs to the server's error logger, or to the standard logger
+// if none is set. The message is prefixed with the ID of the request.
+func (s Server) logf(r *http.Request, format string, v ...interface{}) {
+	if id := requestid.FromContext(r.Context()); id != "" {
+		format = "[" + id + "] " + format
+	}
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
 }
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,28 @@
+package http_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moreirathomas/golastic/internal/http"
+	"github.com/moreirathomas/golastic/internal/repository"
+)
+
+func TestRunShutdown(t *testing.T) {
+	srv := http.NewServer("127.0.0.1:0", repository.Repository{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Run(ctx) }()
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	case <-time.After(srv.ShutdownTimeout):
+		t.Fatal("server did not shut down")
+	}
+}
@@ -10,85 +10,380 @@ import (
 // Ensure Repository implements BookService
 var _ internal.BookService = (*Repository)(nil)
 
-// SearchBooks retrieves books matching the userQuery in the database
+// SearchBooks retrieves books matching the query in the database
 // or the first non-nil error encountered in the process.
-func (r Repository) SearchBooks(userQuery string, size, from int) ([]internal.Book, int, error) {
-	var res *golastic.SearchResult
+func (r Repository) SearchBooks(q internal.BookQuery) (internal.BookResults, error) {
+	var res *golastic.TypedSearchResult[internal.Book]
 	var err error
 
-	if userQuery == "" {
-		res, err = golastic.Search(r.context()).MatchAllQuery(golastic.SearchPagination{Size: size, From: from})
+	search := golastic.SearchOf[internal.Book](r.context()).
+		WithExplain(q.Explain).
+		WithProfile(q.Profile)
+
+	sort, distanceAt := booksSort(q)
+	if q.Query != "" && r.useTemplate {
+		// The search template defines the whole search, including
+		// the returned fields and the collapsing.
+		res, err = search.Template(r.searchTemplateID(), searchTemplateParams(q, sort))
 	} else {
-		res, err = golastic.Search(r.context()).MultiMatchQuery(userQuery,
-			[]golastic.Field{
-				{Name: "title", Weight: 10},
-				{Name: "abstract"},
-			},
-			golastic.SearchPagination{Size: size, From: from},
-			golastic.SearchSort{"_score:asc", "_doc:asc"},
-		)
+		search.WithSource(q.Fields, []string{embeddingField})
+		if q.OnePerAuthor {
+			search.WithCollapse(golastic.Collapse{Field: authorNameField})
+		}
+
+		query := golastic.NewMatchAllQuery()
+		if q.Query != "" {
+			query = fullTextQuery(q.Query)
+			if r.boostRecent {
+				query = boostRecent(query)
+			}
+			if sortedByRelevance(q) {
+				// Rescoring requires the hits to be sorted by score only.
+				search.WithRescore(phraseRescore(q.Query))
+				sort = golastic.SearchSort{golastic.SortByScore()}
+			}
+		}
+
+		p := golastic.SearchPagination{Size: q.Size, From: q.From}
+		res, err = search.Query(booksQuery(query, q), p, sort)
 	}
 	if err != nil {
-		return []internal.Book{}, 0, err
+		return internal.BookResults{}, err
 	}
 
-	results, err := res.UnwrapHits(internal.Book{})
-	if err != nil {
-		return []internal.Book{}, 0, err
+	found := internal.BookResults{
+		Books:             res.Documents,
+		Total:             res.TotalHits(),
+		TotalIsLowerBound: res.TotalHitsRelation() == golastic.RelationGreaterOrEqual,
+		Profile:           res.Profile,
+	}
+	if q.Near != nil {
+		found.Distances = distances(res.SearchResult, distanceAt)
+	}
+	if q.Explain {
+		found.Explanations = explanations(res.SearchResult)
 	}
 
-	books, err := unmarshalHits(results)
-	if err != nil {
-		return []internal.Book{}, 0, fmt.Errorf("failed to unmarshal books: %w", err)
+	return found, nil
+}
+
+// distances returns the distance of each hit by ID, as computed
+// by the geo distance sort at index i of the search sort.
+func distances(res *golastic.SearchResult, i int) map[string]float64 {
+	m := map[string]float64{}
+	if res.Hits == nil {
+		return m
+	}
+	for _, h := range res.Hits.Hits {
+		if len(h.Sort) <= i {
+			continue
+		}
+		if d, ok := h.Sort[i].(float64); ok {
+			m[h.ID] = d
+		}
+	}
+	return m
+}
+
+// explanations returns the score explanation of each hit by ID.
+func explanations(res *golastic.SearchResult) map[string]*golastic.Explanation {
+	m := map[string]*golastic.Explanation{}
+	if res.Hits == nil {
+		return m
+	}
+	for _, h := range res.Hits.Hits {
+		m[h.ID] = h.Explanation
+	}
+	return m
+}
+
+// fullTextQuery returns the query matching the books containing all the
+// terms of the user query, either in their title or in their abstract.
+func fullTextQuery(userQuery string) golastic.SearchQuery {
+	return golastic.NewMultiMatchQuery(userQuery, []golastic.Field{
+		{Name: "title", Weight: 10},
+		{Name: "abstract"},
+	})
+}
+
+// phraseRescore returns the rescorer ranking higher the top books containing
+// the terms of the user query as a phrase, either in their title or in their
+// abstract. The multi_match query of type phrase runs a match_phrase query
+// on each field, which is too costly to run on all the matching books.
+func phraseRescore(userQuery string) golastic.Rescore {
+	return golastic.Rescore{
+		WindowSize: phraseRescoreWindow,
+		Query: golastic.RescoreQuery{
+			RescoreQuery: golastic.Query{MultiMatch: golastic.MultiMatchQuery{
+				Query:  userQuery,
+				Fields: []golastic.Field{{Name: "title", Weight: 10}, {Name: "abstract"}},
+				Type:   "phrase",
+				Slop:   phraseSlop,
+			}},
+			RescoreQueryWeight: phraseWeight,
+		},
+	}
+}
+
+const (
+	// phraseRescoreWindow is the number of top books of each shard
+	// rescored by phraseRescore.
+	phraseRescoreWindow = 50
+
+	// phraseSlop is the number of positions the terms of a phrase
+	// can be moved and still match, e.g. "desert planet" matches
+	// "desert ice planet".
+	phraseSlop = 2
+
+	// phraseWeight is the weight of phrase matches relative
+	// to the original score of the books.
+	phraseWeight = 2
+)
+
+// sortedByRelevance reports whether the books of the given query
+// are sorted by score only, without collapsing.
+func sortedByRelevance(q internal.BookQuery) bool {
+	return len(q.Sort) == 0 && q.Near == nil && !q.OnePerAuthor
+}
+
+// booksQuery returns the given query restricted by the author and location
+// filters of the book query, if any.
+func booksQuery(q golastic.SearchQuery, bq internal.BookQuery) golastic.SearchQuery {
+	filters := []golastic.Query{}
+	if bq.Author != "" {
+		filters = append(filters, authorQuery(bq.Author))
+	}
+	if bq.Near != nil {
+		filters = append(filters, golastic.NewGeoDistanceQuery(
+			branchLocationField, geoPoint(*bq.Near), bq.Within,
+		).Query)
 	}
 
-	return books, res.TotalHits(), nil
+	sq := golastic.SearchQuery{}
+	sq.Query.Bool = &golastic.BoolQuery{
+		Must:   []golastic.Query{q.Query},
+		Filter: filters,
+	}
+	return sq
 }
 
-func unmarshalHits(hits []interface{}) ([]internal.Book, error) {
-	books := make([]internal.Book, 0, len(hits))
-	for _, h := range hits {
-		b, ok := h.(internal.Book)
-		if !ok {
-			return books, fmt.Errorf("hit has invalid book format: %#v", h)
+// booksSort returns the sort of the given book query, along with the index
+// of the distance criterion if the query has a location filter.
+//
+// The books are sorted by the criteria of the query, or by relevance if
+// none. If the query has a location filter, the books are sorted by
+// distance first unless the criteria sort by distance explicitly, so that
+// the distances are always computed. Ties are broken by index order.
+func booksSort(q internal.BookQuery) (golastic.SearchSort, int) {
+	sort := golastic.SearchSort{}
+	distanceAt := -1
+
+	if q.Near != nil && !hasSort(q.Sort, internal.SortByDistance) {
+		sort = append(sort, distanceSort(*q.Near))
+		distanceAt = 0
+	}
+	if len(q.Sort) == 0 && q.Query != "" {
+		sort = append(sort, golastic.SortByScore())
+	}
+
+	for _, s := range q.Sort {
+		var criteria []golastic.Sort
+		switch s.By {
+		case internal.SortByCreatedAt:
+			criteria = append(criteria, order(golastic.SortBy("created_at"), s.Desc))
+		case internal.SortByTitle:
+			criteria = append(criteria, order(golastic.SortBy(titleKeyField), s.Desc))
+		case internal.SortByAuthor:
+			criteria = append(criteria,
+				order(golastic.SortBy("author.lastname.keyword"), s.Desc),
+				order(golastic.SortBy("author.firstname.keyword"), s.Desc),
+			)
+		case internal.SortByScore:
+			criteria = append(criteria, order(golastic.SortBy("_score"), s.Desc))
+		case internal.SortByDistance:
+			if q.Near == nil {
+				continue
+			}
+			d := distanceSort(*q.Near)
+			if s.Desc {
+				d = d.Desc()
+			}
+			distanceAt = len(sort)
+			criteria = append(criteria, d)
 		}
-		books = append(books, b)
+		sort = append(sort, criteria...)
 	}
-	return books, nil
+
+	return append(sort, golastic.SortByDoc()), distanceAt
 }
 
-func (r Repository) GetBookByID(id string) (internal.Book, error) {
-	res, err := golastic.Document(r.context()).Get(id)
-	if err != nil {
-		return internal.Book{}, err
+// hasSort returns whether the given criteria sort by the given value.
+func hasSort(criteria []internal.BookSort, by string) bool {
+	for _, s := range criteria {
+		if s.By == by {
+			return true
+		}
+	}
+	return false
+}
+
+// order returns the given sort in descending order if desc is true,
+// in ascending order otherwise.
+func order(s golastic.FieldSort, desc bool) golastic.FieldSort {
+	if desc {
+		return s.Desc()
 	}
+	return s.Asc()
+}
 
-	result, err := res.Unwrap(internal.Book{})
-	if err != nil {
-		return internal.Book{}, err
+// distanceSort returns the sort by ascending distance in kilometers of
+// the closest branch of the books to the given location.
+func distanceSort(l internal.Location) golastic.GeoDistanceSort {
+	return golastic.SortByDistance(branchLocationField, geoPoint(l)).WithUnit("km")
+}
+
+// titleKeyField is the field holding the exact title of a book.
+const titleKeyField = "title.keyword"
+
+// branchLocationField is the geo_point field holding the location
+// of the branches stocking a book.
+const branchLocationField = "branches.location"
+
+func geoPoint(l internal.Location) golastic.GeoPoint {
+	return golastic.GeoPoint{Lat: l.Lat, Lon: l.Lon}
+}
+
+// authorQuery returns the query matching the books whose main author or
+// one of the co-authors has the given full name. Co-authors are nested
+// so that the first name of one cannot match with the last name of another.
+func authorQuery(name string) golastic.Query {
+	byName := func(path string) golastic.Query {
+		return golastic.Query{MultiMatch: golastic.MultiMatchQuery{
+			Query:    name,
+			Fields:   []golastic.Field{{Name: path + ".firstname"}, {Name: path + ".lastname"}},
+			Type:     "cross_fields",
+			Operator: "and",
+		}}
+	}
+	return golastic.Query{Bool: &golastic.BoolQuery{
+		Should: []golastic.Query{
+			byName("author"),
+			golastic.NewNestedQuery(coAuthorsPath, byName(coAuthorsPath)).Query,
+		},
+		MinimumShouldMatch: "1",
+	}}
+}
+
+// coAuthorsPath is the path of the nested co-authors of a book.
+const coAuthorsPath = "co_authors"
+
+// searchTemplateParams returns the parameters of the search template
+// used for full text searches. All fields are returned if q.Fields is empty.
+func searchTemplateParams(q internal.BookQuery, sort golastic.SearchSort) golastic.TemplateParams {
+	fields := q.Fields
+	if fields == nil {
+		fields = []string{}
 	}
 
-	book, ok := result.(internal.Book)
-	if !ok {
-		return book, fmt.Errorf("response has invalid book format: %#v", res)
+	params := golastic.TemplateParams{
+		"query":          q.Query,
+		"from":           q.From,
+		"size":           q.Size,
+		"fields":         fields,
+		"sort":           sort,
+		"one_per_author": q.OnePerAuthor,
+	}
+	if q.Author != "" {
+		params["author"] = q.Author
+	}
+	if q.Near != nil {
+		params["near"] = golastic.TemplateParams{
+			"lat":    q.Near.Lat,
+			"lon":    q.Near.Lon,
+			"within": q.Within,
+		}
 	}
+	return params
+}
+
+// authorNameField is the keyword field holding the full name of the main
+// author of a book, on which the books are collapsed per author.
+const authorNameField = "author_name"
+
+// authorName returns the full name of the given author,
+// as stored in authorNameField.
+func authorName(a internal.Author) string {
+	return a.Firstname + " " + a.Lastname
+}
+
+// bookDocument is a book as stored in Elasticsearch.
+type bookDocument struct {
+	internal.Book
+	AuthorName string `json:"author_name"`
+}
+
+func newBookDocument(b internal.Book) bookDocument {
+	return bookDocument{Book: b, AuthorName: authorName(b.Author)}
+}
+
+// bookUpdate is a partial update of a book in Elasticsearch. As the update
+// replaces the author of the book, its full name is always updated too.
+type bookUpdate struct {
+	internal.Book
+	AuthorName string `json:"author_name"`
+}
+
+func newBookUpdate(b internal.Book) bookUpdate {
+	return bookUpdate{Book: b, AuthorName: authorName(b.Author)}
+}
+
+// boostRecent returns the given query with the score of recently created
+// books increased by up to recentBoost. A book created within the last
+// week gets the full boost, half of it after 37 days, and the boost fades
+// out for older books.
+//
+// The boost is added to the score of the query rather than multiplying it,
+// so that it mostly reorders books of similar relevance instead of ranking
+// a recent book matching the abstract above an older one matching the title.
+func boostRecent(q golastic.SearchQuery) golastic.SearchQuery {
+	fq := golastic.NewFunctionScoreQuery(q,
+		golastic.ScoreFunction{
+			Weight: recentBoost,
+			Gauss: &golastic.DecayFunction{
+				Field:  "created_at",
+				Origin: "now",
+				Offset: "7d",
+				Scale:  "30d",
+				Decay:  0.5,
+			},
+		},
+	)
+	fq.Query.FunctionScore.BoostMode = golastic.BoostModeSum
+	fq.Query.FunctionScore.MaxBoost = recentBoost
+	return fq
+}
+
+// recentBoost is the maximum score added to recently created books.
+const recentBoost = 1
 
-	return book, nil
+// GetBookByID retrieves a book by its ID.
+// It returns ErrResourceNotFound if the book does not exist.
+func (r Repository) GetBookByID(id string) (internal.Book, error) {
+	b, err := golastic.DocumentOf[internal.Book](r.context()).
+		WithSource(nil, []string{embeddingField}).
+		Get(id)
+	return b, notFound(err, "book", id)
 }
 
 // InsertBook indexes a new book.
 func (r Repository) InsertBook(b internal.Book) (string, error) {
-	res, err := golastic.Document(r.context()).Index(b)
-	if err != nil {
-		return "", fmt.Errorf(
-			"%w failed to insert book %#v: %s",
-			ErrInternal, b, err,
-		)
+	if err := r.embed(&b); err != nil {
+		return "", err
 	}
 
-	id, err := res.Unwrap()
+	id, err := golastic.DocumentOf[bookDocument](r.context()).Index(newBookDocument(b))
 	if err != nil {
-		return "", fmt.Errorf("could not insert book: %w", err)
+		return "", fmt.Errorf("failed to insert book %#v: %w", b, err)
 	}
 
 	return id, nil
@@ -96,40 +391,51 @@ func (r Repository) InsertBook(b internal.Book) (string, error) {
 
 // InsertManyBooks indexes multiple new book documents at once.
 func (r *Repository) InsertManyBooks(books []internal.Book) error {
-	in := make([]interface{}, len(books))
+	docs := make([]bookDocument, len(books))
 	for i, b := range books {
-		in[i] = b
+		if err := r.embed(&b); err != nil {
+			return err
+		}
+		docs[i] = newBookDocument(b)
 	}
 
-	if err := golastic.Document(r.context()).Bulk(in); err != nil {
-		return fmt.Errorf(
-			"%w: failed to insert books: %s",
-			ErrInternal, err,
-		)
+	if err := golastic.DocumentOf[bookDocument](r.context()).Bulk(docs); err != nil {
+		return fmt.Errorf("failed to insert books: %w", err)
 	}
 
 	return nil
 }
 
 // UpdateBook updates the specified book with a partial book input.
+// It returns ErrResourceNotFound if the book does not exist.
 func (r Repository) UpdateBook(b internal.Book) error {
-	err := golastic.Document(r.context()).Update(b.ID, b)
+	cleared, err := r.reembed(&b)
 	if err != nil {
-		return fmt.Errorf(
-			"%w: failed to update book %#v: %s",
-			ErrInternal, b, err,
-		)
+		return err
+	}
+
+	docs := golastic.DocumentOf[bookUpdate](r.context())
+	if err := docs.Update(b.ID, newBookUpdate(b)); err != nil {
+		return fmt.Errorf("failed to update book %#v: %w", b, notFound(err, "book", b.ID))
+	}
+
+	// An empty embedding is omitted from the update: the embedding
+	// of the previous text is removed explicitly.
+	if cleared {
+		if err := docs.UpdateByScript(b.ID, golastic.RemoveScript(embeddingField)); err != nil {
+			return fmt.Errorf("failed to clear the embedding of book %s: %w", b.ID, notFound(err, "book", b.ID))
+		}
 	}
-	// TODO: handle 404 when golastic allows it
 	return nil
 }
 
-// DeleteBook removes the specified book from the index.
+// DeleteBook removes the specified book from the index, along with
+// its reviews.
+// It returns ErrResourceNotFound if the book does not exist.
 func (r Repository) DeleteBook(id string) error {
-	err := golastic.Document(r.context()).Delete(id)
+	err := golastic.DocumentOf[internal.Book](r.context()).Delete(id)
 	if err != nil {
-		return err
+		return notFound(err, "book", id)
 	}
-	// TODO: handle 404 when golastic allows it
-	return nil
+	return r.deleteReviews(id)
 }
@@ -0,0 +1,187 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/moreirathomas/golastic/internal"
+	"github.com/moreirathomas/golastic/internal/repository"
+	"github.com/moreirathomas/golastic/pkg/golastic/golastictest"
+)
+
+func newTestRepository(t *testing.T) *repository.Repository {
+	t.Helper()
+
+	mapping, err := os.ReadFile("../../cmd/mapping.json")
+	if err != nil {
+		t.Fatalf("cannot read mapping: %s", err)
+	}
+
+	repo, err := repository.New(repository.Config{
+		Client:    golastictest.NewClient(t),
+		IndexName: "books",
+		Mapping:   string(mapping),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	err = repo.InsertManyBooks([]internal.Book{
+		{Title: "Dune", Abstract: "A desert planet.", Author: internal.Author{Firstname: "Frank", Lastname: "Herbert"}},
+		{Title: "Children of Dune", Abstract: "The sequel.", Author: internal.Author{Firstname: "Frank", Lastname: "Herbert"}},
+		{Title: "Foundation", Abstract: "The fall of an empire.", Author: internal.Author{Firstname: "Isaac", Lastname: "Asimov"},
+			CoAuthors: []internal.Author{{Firstname: "Frank", Lastname: "Smith"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return repo
+}
+
+func TestSearchBooks(t *testing.T) {
+	repo := newTestRepository(t)
+
+	testCases := []struct {
+		name  string
+		query internal.BookQuery
+		exp   []string
+	}{
+		{
+			name:  "all books in index order",
+			query: internal.BookQuery{Size: 10},
+			exp:   []string{"Dune", "Children of Dune", "Foundation"},
+		},
+		{
+			name:  "full text",
+			query: internal.BookQuery{Query: "dune", Size: 10},
+			exp:   []string{"Dune", "Children of Dune"},
+		},
+		{
+			name:  "by author or co-author",
+			query: internal.BookQuery{Author: "Frank Smith", Size: 10},
+			exp:   []string{"Foundation"},
+		},
+		{
+			name: "sorted by title",
+			query: internal.BookQuery{Size: 2, From: 1, Sort: []internal.BookSort{
+				{By: internal.SortByTitle, Desc: true},
+			}},
+			exp: []string{"Dune", "Children of Dune"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := repo.SearchBooks(tc.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			titles := []string{}
+			for _, b := range res.Books {
+				titles = append(titles, b.Title)
+			}
+			if len(titles) != len(tc.exp) {
+				t.Fatalf("unexpected books: expected %v, got %v", tc.exp, titles)
+			}
+			for i := range titles {
+				if titles[i] != tc.exp[i] {
+					t.Errorf("unexpected books: expected %v, got %v", tc.exp, titles)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchBooksPhraseRescore(t *testing.T) {
+	repo := newTestRepository(t)
+
+	// Matches the terms as well as "Dune", indexed first, but as a phrase.
+	_, err := repo.InsertBook(internal.Book{
+		Title: "Arrakis", Abstract: "The planet desert.",
+		Author: internal.Author{Firstname: "Frank", Lastname: "Herbert"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	res, err := repo.SearchBooks(internal.BookQuery{Query: "planet desert", Size: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	titles := []string{}
+	for _, b := range res.Books {
+		titles = append(titles, b.Title)
+	}
+	exp := []string{"Arrakis", "Dune"}
+	if fmt.Sprint(titles) != fmt.Sprint(exp) {
+		t.Errorf("unexpected books: expected %v, got %v", exp, titles)
+	}
+}
+
+func TestSearchBooksOnePerAuthor(t *testing.T) {
+	repo := newTestRepository(t)
+
+	// An author sharing the last name of another.
+	_, err := repo.InsertBook(internal.Book{
+		Title: "Dune: House Atreides", Abstract: "A prequel.",
+		Author: internal.Author{Firstname: "Brian", Lastname: "Herbert"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	res, err := repo.SearchBooks(internal.BookQuery{OnePerAuthor: true, Size: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	titles := []string{}
+	for _, b := range res.Books {
+		titles = append(titles, b.Title)
+	}
+	exp := []string{"Dune", "Foundation", "Dune: House Atreides"}
+	if fmt.Sprint(titles) != fmt.Sprint(exp) {
+		t.Errorf("unexpected books: expected %v, got %v", exp, titles)
+	}
+}
+
+func TestUpdateAndDeleteBook(t *testing.T) {
+	repo := newTestRepository(t)
+
+	res, err := repo.SearchBooks(internal.BookQuery{Query: "foundation", Size: 1})
+	if err != nil || len(res.Books) != 1 {
+		t.Fatalf("unexpected result: %v, %v", res.Books, err)
+	}
+	id := res.Books[0].ID
+
+	if err := repo.UpdateBook(internal.Book{ID: id, Title: "Foundation and Empire"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b, err := repo.GetBookByID(id)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b.Title != "Foundation and Empire" {
+		t.Errorf("unexpected book: %#v", b)
+	}
+
+	if err := repo.DeleteBook(id); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := repo.GetBookByID(id); !errors.Is(err, repository.ErrResourceNotFound) {
+		t.Errorf("unexpected error: expected %s, got %v", repository.ErrResourceNotFound, err)
+	}
+
+	// The book no longer exists.
+	if err := repo.UpdateBook(internal.Book{ID: id, Title: "Second Foundation"}); !errors.Is(err, repository.ErrResourceNotFound) {
+		t.Errorf("unexpected error: expected %s, got %v", repository.ErrResourceNotFound, err)
+	}
+	if err := repo.DeleteBook(id); !errors.Is(err, repository.ErrResourceNotFound) {
+		t.Errorf("unexpected error: expected %s, got %v", repository.ErrResourceNotFound, err)
+	}
+}
@@ -1,6 +1,11 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
 
 var (
 	// ErrMarshaling is returned when a json result cannot be marshaled
@@ -14,6 +19,19 @@ var (
 	// ErrResourceNotFound is returned when a query by ID has no match.
 	ErrResourceNotFound = errors.New("resource not found")
 
+	// ErrNoEmbedding is returned when a book has no embedding to be
+	// compared with other books.
+	ErrNoEmbedding = errors.New("book has no embedding")
+
 	// ErrInternal is returned when an encountered error could not be identified.
 	ErrInternal = errors.New("repository internal error")
 )
+
+// notFound returns an error wrapping ErrResourceNotFound for the resource
+// of the given ID if err is a golastic.ErrNotFound, err otherwise.
+func notFound(err error, resource, id string) error {
+	if errors.Is(err, golastic.ErrNotFound) {
+		return fmt.Errorf("%w: %s %s", ErrResourceNotFound, resource, id)
+	}
+	return err
+}
@@ -1,13 +1,14 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 
 	"github.com/elastic/go-elasticsearch/v7"
-	"github.com/elastic/go-elasticsearch/v7/esapi"
 
+	"github.com/moreirathomas/golastic/internal"
 	"github.com/moreirathomas/golastic/pkg/golastic"
 )
 
@@ -16,18 +17,73 @@ type Config struct {
 	Client    *elasticsearch.Client
 	IndexName string
 	Mapping   string
+
+	// BoostRecent ranks recently created books higher in full text searches.
+	// It cannot be used along with SearchTemplate.
+	BoostRecent bool
+
+	// Embedder computes the embeddings of the books, used to find similar
+	// books. Books are indexed without embeddings if nil.
+	Embedder internal.Embedder
+
+	// SearchTemplate is the mustache source of the search template used
+	// for full text searches. It is stored in Elasticsearch on startup,
+	// replacing the stored version if any. The template defines the whole
+	// search: the ranking options of the repository do not apply.
+	// Full text searches are built by the repository if empty.
+	SearchTemplate string
+
+	// Instrumentation is notified around each request to Elasticsearch,
+	// e.g. to trace them. It is optional.
+	Instrumentation golastic.Instrumentation
 }
 
 // Repository allows to index and search documents.
 type Repository struct {
-	es        *elasticsearch.Client
-	indexName string
+	es          *elasticsearch.Client
+	indexName   string
+	savedName   string
+	reviewsName string
+	boostRecent bool
+	useTemplate bool
+	embedder    internal.Embedder
+
+	ctx             context.Context //nolint:containedctx // set per request by WithContext
+	instrumentation golastic.Instrumentation
+}
+
+// WithContext returns a copy of the repository sending its requests
+// to Elasticsearch with the given context, typically the context of
+// the HTTP request being served, so that they can be traced.
+func (r Repository) WithContext(ctx context.Context) Repository {
+	r.ctx = ctx
+	return r
 }
 
 func (r Repository) context() golastic.ContextConfig {
 	return golastic.ContextConfig{
-		IndexName: r.indexName,
-		Client:    r.es,
+		IndexName:       r.indexName,
+		Client:          r.es,
+		Context:         r.ctx,
+		Instrumentation: r.instrumentation,
+	}
+}
+
+func (r Repository) reviewsContext() golastic.ContextConfig {
+	return golastic.ContextConfig{
+		IndexName:       r.reviewsName,
+		Client:          r.es,
+		Context:         r.ctx,
+		Instrumentation: r.instrumentation,
+	}
+}
+
+func (r Repository) savedSearchContext() golastic.ContextConfig {
+	return golastic.ContextConfig{
+		IndexName:       r.savedName,
+		Client:          r.es,
+		Context:         r.ctx,
+		Instrumentation: r.instrumentation,
 	}
 }
 
@@ -36,37 +92,173 @@ func New(cfg Config) (*Repository, error) {
 	if cfg.IndexName == "" {
 		return &Repository{}, errors.New("cannot use empty string \"\" as index name")
 	}
+	if cfg.BoostRecent && cfg.SearchTemplate != "" {
+		return &Repository{}, errors.New("cannot boost recent books in searches using a search template")
+	}
 
 	repo := Repository{
-		es:        cfg.Client,
-		indexName: cfg.IndexName,
+		es:          cfg.Client,
+		indexName:   cfg.IndexName,
+		savedName:   cfg.IndexName + "-saved-searches",
+		reviewsName: cfg.IndexName + "-reviews",
+		boostRecent: cfg.BoostRecent,
+		useTemplate: cfg.SearchTemplate != "",
+		embedder:    cfg.Embedder,
+
+		instrumentation: cfg.Instrumentation,
+	}
+
+	mapping := cfg.Mapping
+	if repo.embedder != nil {
+		m, err := golastic.DenseVectorMapping(mapping, embeddingField, repo.embedder.Dims())
+		if err != nil {
+			return nil, fmt.Errorf("cannot create embedding mapping: %s", err)
+		}
+		mapping = m
 	}
 
-	if err := repo.setupIndex(cfg.Mapping); err != nil {
+	if err := repo.setupIndex(mapping); err != nil {
 		return nil, err
 	}
 
+	if err := repo.setupReviewsIndex(); err != nil {
+		return nil, err
+	}
+
+	if err := repo.setupSavedSearchIndex(mapping); err != nil {
+		return nil, err
+	}
+
+	if repo.useTemplate {
+		if err := repo.setupSearchTemplate(cfg.SearchTemplate); err != nil {
+			return nil, err
+		}
+	}
+
 	return &repo, nil
 }
 
-func (r *Repository) setupIndex(mapping string) error {
-	isCreate, err := golastic.Indices(r.es).CreateIfNotExists(r.indexName, mapping)
+// setupIndex creates the books index.
+//
+// If the index already exists, the fields added to the mapping since its
+// creation are added to its mapping, and the existing books are migrated.
+func (r *Repository) setupIndex(m string) error {
+	indices := golastic.Indices(r.es).WithInstrumentation(r.instrumentation)
+	isCreate, err := indices.CreateIfNotExists(r.indexName, m)
 	if isCreate {
 		log.Println("Creating Elasticsearch index with mapping")
 	}
 	if err != nil {
 		return fmt.Errorf("cannot create index: %s", err)
 	}
+	if isCreate {
+		return nil
+	}
+
+	if err := indices.PutMapping(r.indexName, m); err != nil {
+		return fmt.Errorf("cannot update index mapping: %s", err)
+	}
+	return r.migrateBooks()
+}
+
+// migrateBooks sets the fields of the books indexed before they were added
+// to the mapping.
+func (r *Repository) migrateBooks() error {
+	q := golastic.SearchQuery{}
+	q.Query.Bool = &golastic.BoolQuery{
+		Filter:  []golastic.Query{{Exists: &golastic.ExistsQuery{Field: "author.lastname"}}},
+		MustNot: []golastic.Query{{Exists: &golastic.ExistsQuery{Field: authorNameField}}},
+	}
+
+	n, err := golastic.DocumentOf[bookDocument](r.context()).UpdateByQuery(q, golastic.Script{
+		// Same as authorName.
+		Source: "ctx._source." + authorNameField + " = ctx._source.author.firstname + ' ' + ctx._source.author.lastname",
+	})
+	if err != nil {
+		return fmt.Errorf("cannot migrate books: %s", err)
+	}
+	if n != 0 {
+		log.Printf("Migrated %d books", n)
+	}
+
+	return nil
+}
+
+// setupReviewsIndex creates the reviews index.
+func (r *Repository) setupReviewsIndex() error {
+	isCreate, err := golastic.Indices(r.es).WithInstrumentation(r.instrumentation).CreateIfNotExists(r.reviewsName, reviewsMapping)
+	if isCreate {
+		log.Println("Creating Elasticsearch reviews index with mapping")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create reviews index: %s", err)
+	}
+
+	return nil
+}
+
+// setupSavedSearchIndex creates the saved searches index. Its mapping
+// extends the books mapping so that saved searches can be percolated.
+func (r *Repository) setupSavedSearchIndex(mapping string) error {
+	m, err := golastic.PercolatorMapping(mapping, percolatorField)
+	if err != nil {
+		return fmt.Errorf("cannot create saved searches mapping: %s", err)
+	}
+
+	isCreate, err := golastic.Indices(r.es).WithInstrumentation(r.instrumentation).CreateIfNotExists(r.savedName, m)
+	if isCreate {
+		log.Println("Creating Elasticsearch saved searches index with mapping")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create saved searches index: %s", err)
+	}
+
+	return nil
+}
+
+// setupSearchTemplate stores the search template, so that changes
+// to its source are applied on startup.
+func (r *Repository) setupSearchTemplate(source string) error {
+	log.Println("Storing Elasticsearch search template")
+	err := golastic.Templates(r.es).WithInstrumentation(r.instrumentation).Put(r.searchTemplateID(), source)
+	if err != nil {
+		return fmt.Errorf("cannot store search template: %s", err)
+	}
 
 	return nil
 }
 
-// Info returns basic information about the Elasticsearch client.
-func (r *Repository) Info() (*esapi.Response, error) {
-	res, err := r.es.Info()
+// searchTemplateID returns the ID of the search template used
+// for full text searches.
+func (r Repository) searchTemplateID() string {
+	return r.indexName + "-search"
+}
+
+// Health describes the health of Elasticsearch and of the books index.
+type Health struct {
+	Cluster *golastic.ClusterHealth `json:"cluster"`
+	Index   *golastic.IndexStatus   `json:"index"`
+}
+
+// Ready reports whether the repository can serve requests: the books index
+// exists and the cluster status is not red.
+func (h Health) Ready() bool {
+	return h.Index.Exists && h.Cluster.Status != golastic.HealthRed
+}
+
+// Health returns the health of Elasticsearch and of the books index.
+// It returns an error wrapping golastic.ErrUnavailable if Elasticsearch
+// is not reachable.
+func (r Repository) Health() (Health, error) {
+	cluster, err := golastic.Cluster(r.es).WithContext(r.ctx).WithInstrumentation(r.instrumentation).Health()
+	if err != nil {
+		return Health{}, err
+	}
+
+	index, err := golastic.Indices(r.es).WithContext(r.ctx).WithInstrumentation(r.instrumentation).Status(r.indexName)
 	if err != nil {
-		return nil, fmt.Errorf("error: %s", err)
+		return Health{}, err
 	}
 
-	return res, nil
+	return Health{Cluster: cluster, Index: index}, nil
 }
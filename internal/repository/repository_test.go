@@ -0,0 +1,26 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestHealth(t *testing.T) {
+	repo := newTestRepository(t)
+
+	health, err := repo.Health()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !health.Ready() {
+		t.Errorf("expected repository to be ready, got %+v", health)
+	}
+	if health.Cluster.Status != golastic.HealthGreen {
+		t.Errorf("unexpected cluster status: expected %s, got %s", golastic.HealthGreen, health.Cluster.Status)
+	}
+	if exp, got := 3, health.Index.DocCount; got != exp {
+		t.Errorf("unexpected doc count: expected %d, got %d", exp, got)
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/moreirathomas/golastic/internal"
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// Ensure Repository implements ReviewService
+var _ internal.ReviewService = (*Repository)(nil)
+
+// reviewsMapping is the mapping of the reviews index. Reviews are stored
+// in their own index rather than as children of their book through a join
+// field: the books mapping and searches are left untouched, and the reviews
+// of a book are deleted along with it by a query on book_id.
+const reviewsMapping = `{
+  "mappings": {
+    "properties": {
+      "book_id": {
+        "type": "keyword"
+      },
+      "created_at": {
+        "type": "date"
+      },
+      "rating": {
+        "type": "integer"
+      },
+      "comment": {
+        "type": "text",
+        "analyzer": "english"
+      }
+    }
+  }
+}`
+
+// InsertReview indexes a new review of an existing book.
+func (r Repository) InsertReview(rv internal.Review) (string, error) {
+	if _, err := r.GetBookByID(rv.BookID); err != nil {
+		return "", err
+	}
+
+	id, err := golastic.DocumentOf[internal.Review](r.reviewsContext()).Index(rv)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert review %#v: %w", rv, err)
+	}
+
+	return id, nil
+}
+
+// BookReviews retrieves the most recent reviews of the book of the given ID,
+// along with the average rating of all its reviews.
+func (r Repository) BookReviews(bookID string, size int) (internal.ReviewResults, error) {
+	if _, err := r.GetBookByID(bookID); err != nil {
+		return internal.ReviewResults{}, err
+	}
+
+	res, err := golastic.SearchOf[internal.Review](r.reviewsContext()).
+		WithAggregations(map[string]golastic.Aggregation{
+			"rating": {Avg: &golastic.MetricAggregation{Field: "rating"}},
+		}).
+		Query(reviewsOf(bookID), golastic.SearchPagination{Size: size}, golastic.SearchSort{golastic.SortBy("created_at").Desc()})
+	if err != nil {
+		return internal.ReviewResults{}, err
+	}
+
+	found := internal.ReviewResults{
+		Reviews: res.Documents,
+		Total:   res.TotalHits(),
+	}
+	if rating, ok := res.Aggregations["rating"]; ok {
+		found.AverageRating = rating.Value
+	}
+
+	return found, nil
+}
+
+// deleteReviews removes the reviews of the book of the given ID.
+func (r Repository) deleteReviews(bookID string) error {
+	if _, err := golastic.DocumentOf[internal.Review](r.reviewsContext()).DeleteByQuery(reviewsOf(bookID)); err != nil {
+		return fmt.Errorf("failed to delete reviews of book %s: %w", bookID, err)
+	}
+	return nil
+}
+
+// reviewsOf returns the query matching the reviews of the book of the given ID.
+func reviewsOf(bookID string) golastic.SearchQuery {
+	q := golastic.SearchQuery{}
+	q.Query.Term = &golastic.TermQuery{Field: "book_id", Value: bookID}
+	return q
+}
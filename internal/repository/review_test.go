@@ -0,0 +1,60 @@
+package repository_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/moreirathomas/golastic/internal"
+	"github.com/moreirathomas/golastic/internal/repository"
+	"github.com/moreirathomas/golastic/pkg/golastic/golastictest"
+)
+
+func TestDeleteBookDeletesReviews(t *testing.T) {
+	mapping, err := os.ReadFile("../../cmd/mapping.json")
+	if err != nil {
+		t.Fatalf("cannot read mapping: %s", err)
+	}
+
+	srv := golastictest.NewServer()
+	defer srv.Close()
+
+	repo, err := repository.New(repository.Config{
+		Client:    srv.Client(),
+		IndexName: "books",
+		Mapping:   string(mapping),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dune, err := repo.InsertBook(internal.Book{Title: "Dune"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	foundation, err := repo.InsertBook(internal.Book{Title: "Foundation"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	reviews := []internal.Review{
+		{BookID: dune, Rating: 5},
+		{BookID: dune, Rating: 4},
+		{BookID: foundation, Rating: 3},
+	}
+	for _, rv := range reviews {
+		if _, err := repo.InsertReview(rv); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if err := repo.DeleteBook(dune); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n := len(srv.Documents("books")); n != 1 {
+		t.Errorf("unexpected books count: expected 1, got %d", n)
+	}
+	if n := len(srv.Documents("books-reviews")); n != 1 {
+		t.Errorf("unexpected reviews count: expected 1, got %d", n)
+	}
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/moreirathomas/golastic/internal"
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// Ensure Repository implements SavedSearchService
+var _ internal.SavedSearchService = (*Repository)(nil)
+
+const (
+	// percolatorField is the field of the saved searches index
+	// storing the percolator query.
+	percolatorField = "query"
+
+	// savedSearchPageSize is the number of matching saved searches
+	// updated at once when recording a book.
+	savedSearchPageSize = 500
+)
+
+// savedSearchDocument is a saved search as stored in Elasticsearch.
+type savedSearchDocument struct {
+	CreatedAt time.Time       `json:"created_at"`
+	UserQuery string          `json:"user_query"`
+	BookIDs   []string        `json:"book_ids"`
+	Query     *golastic.Query `json:"query,omitempty"` // The percolator query.
+}
+
+// UnmarshalHit returns a new hit for Elasticsearch search result that can be
+// later casted as a SavedSearch.
+func (d savedSearchDocument) UnmarshalHit(h golastic.Hit) (interface{}, error) {
+	// The percolator query is not decoded as it is of no use once stored.
+	var doc struct {
+		CreatedAt time.Time `json:"created_at"`
+		UserQuery string    `json:"user_query"`
+		BookIDs   []string  `json:"book_ids"`
+	}
+	if err := json.Unmarshal(h.Source, &doc); err != nil {
+		return internal.SavedSearch{}, err
+	}
+	return internal.SavedSearch{
+		ID:        h.ID,
+		CreatedAt: doc.CreatedAt,
+		Query:     doc.UserQuery,
+		BookIDs:   doc.BookIDs,
+	}, nil
+}
+
+// InsertSavedSearch indexes a new saved search. The search query is stored
+// as a percolator query, matching the books as SearchBooks would.
+func (r Repository) InsertSavedSearch(s internal.SavedSearch) (string, error) {
+	q := fullTextQuery(s.Query).Query
+	doc := savedSearchDocument{
+		CreatedAt: s.CreatedAt,
+		UserQuery: s.Query,
+		BookIDs:   []string{},
+		Query:     &q,
+	}
+
+	res, err := golastic.Document(r.savedSearchContext()).Index(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert saved search %#v: %w", s, err)
+	}
+
+	id, err := res.Unwrap()
+	if err != nil {
+		return "", fmt.Errorf("could not insert saved search: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetSavedSearchByID retrieves a saved search by its ID.
+// It returns ErrResourceNotFound if the saved search does not exist.
+func (r Repository) GetSavedSearchByID(id string) (internal.SavedSearch, error) {
+	res, err := golastic.Document(r.savedSearchContext()).Get(id)
+	if err != nil {
+		return internal.SavedSearch{}, notFound(err, "saved search", id)
+	}
+
+	result, err := res.Unwrap(savedSearchDocument{})
+	if err != nil {
+		return internal.SavedSearch{}, err
+	}
+
+	s, ok := result.(internal.SavedSearch)
+	if !ok {
+		return s, fmt.Errorf("response has invalid saved search format: %#v", res)
+	}
+
+	return s, nil
+}
+
+// MatchSavedSearches percolates the given book against the saved searches
+// and appends its ID to each matching saved search. The matching saved
+// searches are updated page after page, with one bulk request per page.
+func (r Repository) MatchSavedSearches(b internal.Book) ([]string, error) {
+	ids := []string{}
+	err := golastic.Search(r.savedSearchContext()).
+		WithSource(nil, []string{"*"}).
+		EachPage(golastic.NewPercolateQuery(percolatorField, b), savedSearchPageSize, func(res *golastic.SearchResult) error {
+			page := make([]string, 0, len(res.Hits.Hits))
+			for _, h := range res.Hits.Hits {
+				page = append(page, h.ID)
+			}
+
+			err := golastic.Document(r.savedSearchContext()).BulkUpdateByScript(page, golastic.AppendScript("book_ids", b.ID))
+			if err != nil {
+				return err
+			}
+			ids = append(ids, page...)
+			return nil
+		})
+	if err != nil {
+		return ids, fmt.Errorf("failed to record book %s in saved searches: %w", b.ID, err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/moreirathomas/golastic/internal"
+)
+
+func TestMatchSavedSearches(t *testing.T) {
+	repo := newTestRepository(t)
+
+	// More matching saved searches than updated at once.
+	matching := map[string]bool{}
+	for i := 0; i < 1200; i++ {
+		id, err := repo.InsertSavedSearch(internal.SavedSearch{CreatedAt: time.Now(), Query: "desert planet"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		matching[id] = true
+	}
+	other, err := repo.InsertSavedSearch(internal.SavedSearch{CreatedAt: time.Now(), Query: "empire"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	book := internal.Book{ID: "arrakis", Title: "Arrakis", Abstract: "The desert planet."}
+	ids, err := repo.MatchSavedSearches(book)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(ids) != len(matching) {
+		t.Fatalf("unexpected number of matching saved searches: expected %d, got %d", len(matching), len(ids))
+	}
+	for _, id := range ids {
+		if !matching[id] {
+			t.Fatalf("unexpected matching saved search %s", id)
+		}
+	}
+
+	// Matching again does not record the book twice.
+	if _, err := repo.MatchSavedSearches(book); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	s, err := repo.GetSavedSearchByID(ids[len(ids)-1])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if fmt.Sprint(s.BookIDs) != "[arrakis]" {
+		t.Errorf("unexpected book IDs: expected [arrakis], got %v", s.BookIDs)
+	}
+
+	s, err = repo.GetSavedSearchByID(other)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(s.BookIDs) != 0 {
+		t.Errorf("unexpected book IDs: expected none, got %v", s.BookIDs)
+	}
+}
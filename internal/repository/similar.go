@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/moreirathomas/golastic/internal"
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+const (
+	// embeddingField is the dense_vector field holding the embedding of a book.
+	embeddingField = "embedding"
+
+	// relatedMinFreq is the minimum term and document frequency of the
+	// terms selected to find related books. Elasticsearch defaults are too
+	// high for short texts such as titles.
+	relatedMinFreq = 1
+)
+
+// RelatedBooks retrieves the books sharing the most relevant terms of the
+// title and abstract of the book of the given ID, excluding this book.
+func (r Repository) RelatedBooks(id string, size int) ([]internal.Book, error) {
+	// Ensure the book exists as more_like_this ignores unknown documents.
+	if _, err := r.GetBookByID(id); err != nil {
+		return nil, err
+	}
+
+	q := golastic.NewMoreLikeThisQuery(
+		[]string{"title", "abstract"},
+		golastic.LikeDocument(r.indexName, id),
+	)
+	q.Query.MoreLikeThis.MinTermFreq = relatedMinFreq
+	q.Query.MoreLikeThis.MinDocFreq = relatedMinFreq
+
+	res, err := golastic.SearchOf[internal.Book](r.context()).
+		WithSource(nil, []string{embeddingField}).
+		Query(q, golastic.SearchPagination{Size: size}, golastic.SearchSort{golastic.SortByScore()})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Documents, nil
+}
+
+// SimilarBooks retrieves the books whose embedding is the most similar
+// to the embedding of the book of the given ID, excluding this book.
+// It returns ErrNoEmbedding if the book has no embedding.
+func (r Repository) SimilarBooks(id string, size int) ([]internal.Book, error) {
+	book, err := golastic.DocumentOf[internal.Book](r.context()).Get(id)
+	if err != nil {
+		return nil, notFound(err, "book", id)
+	}
+	if len(book.Embedding) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoEmbedding, id)
+	}
+
+	// Only books with an embedding can be scored by similarity.
+	candidates := golastic.SearchQuery{}
+	candidates.Query.Bool = &golastic.BoolQuery{
+		Filter:  []golastic.Query{{Exists: &golastic.ExistsQuery{Field: embeddingField}}},
+		MustNot: []golastic.Query{{IDs: &golastic.IDsQuery{Values: []string{id}}}},
+	}
+
+	res, err := golastic.SearchOf[internal.Book](r.context()).
+		WithSource(nil, []string{embeddingField}).
+		Query(
+			golastic.NewVectorSimilarityQuery(candidates, embeddingField, book.Embedding, golastic.CosineSimilarity),
+			golastic.SearchPagination{Size: size},
+			golastic.SearchSort{golastic.SortByScore()},
+		)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Documents, nil
+}
+
+// embed sets the embedding of the given book, replacing any embedding
+// set by the caller. The embedding is nil if the repository has no
+// embedder, or if the book has no text to embed, as a zero vector
+// cannot be scored by cosine similarity.
+func (r Repository) embed(b *internal.Book) error {
+	b.Embedding = nil
+	if r.embedder == nil {
+		return nil
+	}
+
+	v, err := r.embedder.Embed(b.EmbeddingText())
+	if err != nil {
+		return fmt.Errorf("%w: failed to compute book embedding: %s", ErrInternal, err)
+	}
+	if !isZero(v) {
+		b.Embedding = v
+	}
+	return nil
+}
+
+// reembed sets the embedding of the given partial book if it updates
+// the embedded text. The missing parts of the text are retrieved from
+// the stored book. It reports whether the stored embedding must be
+// removed, as the new text has no embedding.
+func (r Repository) reembed(b *internal.Book) (bool, error) {
+	b.Embedding = nil
+	if r.embedder == nil || (b.Title == "" && b.Abstract == "") {
+		return false, nil
+	}
+
+	stored, err := r.GetBookByID(b.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if b.Title != "" {
+		stored.Title = b.Title
+	}
+	if b.Abstract != "" {
+		stored.Abstract = b.Abstract
+	}
+	if err := r.embed(&stored); err != nil {
+		return false, err
+	}
+
+	b.Embedding = stored.Embedding
+	return b.Embedding == nil, nil
+}
+
+func isZero(v []float32) bool {
+	for _, x := range v {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package repository_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/moreirathomas/golastic/internal"
+	"github.com/moreirathomas/golastic/internal/repository"
+	"github.com/moreirathomas/golastic/pkg/embedding"
+	"github.com/moreirathomas/golastic/pkg/golastic/golastictest"
+)
+
+func TestSimilarBooksWithoutWords(t *testing.T) {
+	mapping, err := os.ReadFile("../../cmd/mapping.json")
+	if err != nil {
+		t.Fatalf("cannot read mapping: %s", err)
+	}
+
+	embedder, err := embedding.NewHash(8)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	repo, err := repository.New(repository.Config{
+		Client:    golastictest.NewClient(t),
+		IndexName: "books",
+		Mapping:   string(mapping),
+		Embedder:  embedder,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The text has no words, its zero vector must not be indexed.
+	id, err := repo.InsertBook(internal.Book{Title: "?!", Abstract: "..."})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := repo.SimilarBooks(id, 10); !errors.Is(err, repository.ErrNoEmbedding) {
+		t.Errorf("unexpected error: expected %s, got %v", repository.ErrNoEmbedding, err)
+	}
+
+	// The embedding of the previous text is removed when the new text
+	// has no words.
+	id, err = repo.InsertBook(internal.Book{Title: "Dune", Abstract: "A desert planet."})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := repo.UpdateBook(internal.Book{ID: id, Title: "?!", Abstract: "..."}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := repo.SimilarBooks(id, 10); !errors.Is(err, repository.ErrNoEmbedding) {
+		t.Errorf("unexpected error after update: expected %s, got %v", repository.ErrNoEmbedding, err)
+	}
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// Review represents a review of a book by a reader.
+type Review struct {
+	ID        string    `json:"id,omitempty"`
+	BookID    string    `json:"book_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Rating    int       `json:"rating"` // From 1 to 5.
+	Comment   string    `json:"comment,omitempty"`
+}
+
+// ReviewResults holds the reviews of a book.
+type ReviewResults struct {
+	Reviews []Review
+
+	// Total is the number of reviews of the book.
+	Total int
+
+	// AverageRating is the average rating of all the reviews of the book.
+	// It is nil if the book has no reviews.
+	AverageRating *float64
+}
+
+// ReviewService gathers repository methods to manage the reviews of books.
+type ReviewService interface {
+
+	// InsertReview adds the given review of a book in the repository.
+	// It returns the ID of the newly inserted review.
+	InsertReview(r Review) (string, error)
+
+	// BookReviews retrieves the most recent reviews of the book
+	// of the given ID.
+	BookReviews(bookID string, size int) (ReviewResults, error)
+}
+
+// Validate return a non-nil error if the review receiver does not match
+// the validation requirements.
+func (r Review) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Rating, validation.Required, validation.Min(1), validation.Max(5)),
+		validation.Field(&r.Comment, validation.Length(0, 2000)),
+	)
+}
+
+// SetMetadata sets the ID of the review from its Elasticsearch hit.
+func (r *Review) SetMetadata(h golastic.Hit) {
+	r.ID = h.ID
+}
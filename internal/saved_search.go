@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// SavedSearch represents a full text query saved by a user to be notified
+// of the new books matching it.
+type SavedSearch struct {
+	ID        string    `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Query     string    `json:"query"`
+
+	// BookIDs lists the books matching the query inserted after
+	// the search was saved.
+	BookIDs []string `json:"book_ids"`
+}
+
+// SavedSearchService gathers repository methods to manage saved searches.
+type SavedSearchService interface {
+
+	// InsertSavedSearch adds the given saved search in the repository.
+	// It returns the ID of the newly inserted saved search.
+	InsertSavedSearch(s SavedSearch) (string, error)
+
+	// GetSavedSearchByID retrieves a saved search by its ID in the repository.
+	GetSavedSearchByID(id string) (SavedSearch, error)
+
+	// MatchSavedSearches records the given book in the saved searches
+	// it matches. It returns the IDs of the matching saved searches.
+	MatchSavedSearches(book Book) ([]string, error)
+}
+
+// Validate return a non-nil error if the saved search receiver does not
+// match the validation requirements.
+func (s SavedSearch) Validate() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Query, validation.Required, validation.Length(1, 200)),
+	)
+}
@@ -0,0 +1,78 @@
+// Package embedding computes vector representations of texts.
+package embedding
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Hash is a deterministic embedder relying on feature hashing: each word
+// of a text is hashed to one of the dimensions of the vector.
+//
+// It does not capture semantics beyond shared words but requires no model,
+// which makes it suitable for tests and local development.
+type Hash struct {
+	dims int
+}
+
+// NewHash returns a Hash embedder computing vectors of the given dimensions.
+func NewHash(dims int) (*Hash, error) {
+	if dims < 1 {
+		return nil, errors.New("dimensions must be positive")
+	}
+	return &Hash{dims: dims}, nil
+}
+
+// Dims returns the dimensions of the computed vectors.
+func (e Hash) Dims() int {
+	return e.dims
+}
+
+// Embed returns the vector representation of the given text, normalized
+// to unit length. A text without words is represented by a zero vector.
+func (e Hash) Embed(text string) ([]float32, error) {
+	v := make([]float64, e.dims)
+	for _, w := range words(text) {
+		h := fnv.New32a()
+		h.Write([]byte(w))
+		sum := h.Sum32()
+
+		// The highest bit sets the sign, so that collisions cancel out
+		// rather than accumulate on average.
+		sign := 1.0
+		if sum&(1<<31) != 0 {
+			sign = -1.0
+		}
+		v[int(sum%uint32(e.dims))] += sign
+	}
+
+	return normalize(v), nil
+}
+
+// words returns the lowercase words of the given text.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// normalize returns v scaled to unit length as float32 values.
+func normalize(v []float64) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	norm = math.Sqrt(norm)
+
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	for i, x := range v {
+		out[i] = float32(x / norm)
+	}
+	return out
+}
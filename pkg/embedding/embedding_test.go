@@ -0,0 +1,38 @@
+package embedding_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/embedding"
+)
+
+func TestHash(t *testing.T) {
+	e, err := embedding.NewHash(16)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	a, _ := e.Embed("The wizard school")
+	b, _ := e.Embed("the WIZARD, school!")
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected equal vectors for texts with the same words, got %v and %v", a, b)
+	}
+
+	if len(a) != 16 {
+		t.Errorf("unexpected dimensions: expected 16, got %d", len(a))
+	}
+
+	var norm float64
+	for _, x := range a {
+		norm += float64(x * x)
+	}
+	if math.Abs(norm-1) > 1e-6 {
+		t.Errorf("expected unit vector, got squared norm %f", norm)
+	}
+
+	if _, err := embedding.NewHash(0); err == nil {
+		t.Error("expected error for zero dimensions")
+	}
+}
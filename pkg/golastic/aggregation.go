@@ -0,0 +1,165 @@
+// This file regroups the entities used to aggregate the hits of a search
+// and to read the aggregation results.
+
+package golastic
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Aggregation holds a single Elasticsearch aggregation. It is shaped as
+// expected from Elasticsearch. Only one of its aggregation fields must be
+// used at a time, along with optional sub-aggregations.
+type Aggregation struct {
+	Terms *TermsAggregation  `json:"terms,omitempty"`
+	Avg   *MetricAggregation `json:"avg,omitempty"`
+	Max   *MetricAggregation `json:"max,omitempty"`
+	Min   *MetricAggregation `json:"min,omitempty"`
+
+	Nested        *NestedAggregation        `json:"nested,omitempty"`
+	ReverseNested *ReverseNestedAggregation `json:"reverse_nested,omitempty"`
+	Children      *ChildrenAggregation      `json:"children,omitempty"`
+
+	GeoDistance *GeoDistanceAggregation `json:"geo_distance,omitempty"`
+
+	// Aggregations are computed for each bucket of the aggregation.
+	Aggregations map[string]Aggregation `json:"aggs,omitempty"`
+}
+
+// TermsAggregation is the bucket aggregation grouping the documents
+// by the values of a keyword or numeric field.
+type TermsAggregation struct {
+	Field string `json:"field"`
+	Size  int    `json:"size,omitempty"` // Defaults to 10.
+}
+
+// MetricAggregation is a single-value metric aggregation computed
+// over the values of a numeric field.
+type MetricAggregation struct {
+	Field string `json:"field"`
+}
+
+// NestedAggregation is the bucket aggregation moving the context of
+// its sub-aggregations to the nested objects at Path.
+type NestedAggregation struct {
+	Path string `json:"path"`
+}
+
+// ReverseNestedAggregation is the bucket aggregation moving the context
+// of its sub-aggregations from nested objects back to the root documents,
+// or to the nested objects at Path if set.
+type ReverseNestedAggregation struct {
+	Path string `json:"path,omitempty"`
+}
+
+// ChildrenAggregation is the bucket aggregation moving the context of
+// its sub-aggregations to the child documents of the given Type.
+type ChildrenAggregation struct {
+	Type string `json:"type"`
+}
+
+// AggregationResult is the result of an aggregation. Depending on the
+// aggregation, it holds either a value, a document count or buckets, and
+// the results of its sub-aggregations.
+type AggregationResult struct {
+	Value    *float64 // Nil for metrics computed over no values.
+	DocCount int
+	Buckets  []*Bucket
+
+	// Aggregations holds the results of the sub-aggregations by name.
+	Aggregations map[string]*AggregationResult
+}
+
+// Bucket is a group of documents of a bucket aggregation.
+type Bucket struct {
+	AggregationResult
+
+	Key         interface{} `json:"key"`
+	KeyAsString string      `json:"key_as_string,omitempty"`
+
+	// From and To are the bounds of the bucket of a range aggregation.
+	From *float64 `json:"from,omitempty"`
+	To   *float64 `json:"to,omitempty"`
+}
+
+// aggregationKeys are the keys of an aggregation result
+// that are not sub-aggregations.
+var aggregationKeys = map[string]bool{
+	"value":                       true,
+	"value_as_string":             true,
+	"doc_count":                   true,
+	"doc_count_error_upper_bound": true,
+	"sum_other_doc_count":         true,
+	"buckets":                     true,
+	"key":                         true,
+	"key_as_string":               true,
+	"from":                        true,
+	"from_as_string":              true,
+	"to":                          true,
+	"to_as_string":                true,
+	"meta":                        true,
+}
+
+// UnmarshalJSON decodes an aggregation result. The sub-aggregations are
+// returned by Elasticsearch alongside the other keys of the result.
+func (r *AggregationResult) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	if v, ok := m["value"]; ok {
+		if err := json.Unmarshal(v, &r.Value); err != nil {
+			return err
+		}
+	}
+	if v, ok := m["doc_count"]; ok {
+		if err := json.Unmarshal(v, &r.DocCount); err != nil {
+			return err
+		}
+	}
+	if v, ok := m["buckets"]; ok {
+		if err := json.Unmarshal(v, &r.Buckets); err != nil {
+			return err
+		}
+	}
+
+	for k, v := range m {
+		if aggregationKeys[k] || !bytes.HasPrefix(bytes.TrimSpace(v), []byte("{")) {
+			continue
+		}
+		var sub AggregationResult
+		if err := json.Unmarshal(v, &sub); err != nil {
+			return err
+		}
+		if r.Aggregations == nil {
+			r.Aggregations = map[string]*AggregationResult{}
+		}
+		r.Aggregations[k] = &sub
+	}
+
+	return nil
+}
+
+// UnmarshalJSON decodes a bucket of a bucket aggregation.
+func (b *Bucket) UnmarshalJSON(p []byte) error {
+	if err := b.AggregationResult.UnmarshalJSON(p); err != nil {
+		return err
+	}
+
+	var key struct {
+		Key         interface{} `json:"key"`
+		KeyAsString string      `json:"key_as_string"`
+		From        *float64    `json:"from"`
+		To          *float64    `json:"to"`
+	}
+	if err := json.Unmarshal(p, &key); err != nil {
+		return err
+	}
+	b.Key = key.Key
+	b.KeyAsString = key.KeyAsString
+	b.From = key.From
+	b.To = key.To
+	return nil
+}
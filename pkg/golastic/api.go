@@ -1,11 +1,22 @@
 package golastic
 
-import "github.com/elastic/go-elasticsearch/v7"
+import (
+	"context"
+
+	"github.com/elastic/go-elasticsearch/v7"
+)
 
 // ContextConfig configures the context for a Elasticsearch API call.
 type ContextConfig struct {
 	Client    *elasticsearch.Client
 	IndexName string
+
+	// Context is the context of the requests, carrying their deadline
+	// and trace. context.Background() is used if nil.
+	Context context.Context //nolint:containedctx // the config is short-lived
+
+	// Instrumentation is notified around each API call if not nil.
+	Instrumentation Instrumentation
 }
 
 // Indices interfaces Elasticsearch Indices API.
@@ -18,15 +29,31 @@ func Indices(c *elasticsearch.Client) *IndicesAPI {
 // Document interfaces Elasticsearch Document API.
 func Document(cfg ContextConfig) *DocumentAPI {
 	return &DocumentAPI{
-		client: cfg.Client,
-		index:  cfg.IndexName,
+		client:      cfg.Client,
+		index:       cfg.IndexName,
+		instruments: newInstruments(cfg),
 	}
 }
 
 // Search interfaces Elasticsearch Search API.
 func Search(cfg ContextConfig) *SearchAPI {
 	return &SearchAPI{
-		client: cfg.Client,
-		index:  cfg.IndexName,
+		client:      cfg.Client,
+		index:       cfg.IndexName,
+		instruments: newInstruments(cfg),
+	}
+}
+
+// Templates interfaces Elasticsearch stored search templates.
+func Templates(c *elasticsearch.Client) *TemplatesAPI {
+	return &TemplatesAPI{
+		client: c,
+	}
+}
+
+// Cluster interfaces Elasticsearch Ping and Cluster Health APIs.
+func Cluster(c *elasticsearch.Client) *ClusterAPI {
+	return &ClusterAPI{
+		client: c,
 	}
 }
@@ -0,0 +1,44 @@
+// This file regroups the entities used to build compound and term-level
+// queries, mostly used to filter the documents of another query.
+
+package golastic
+
+import "github.com/clarketm/json"
+
+// BoolQuery is the query for combining other queries with boolean clauses.
+// Filter and MustNot clauses do not contribute to the score.
+type BoolQuery struct {
+	Must               []Query `json:"must,omitempty"`
+	Filter             []Query `json:"filter,omitempty"`
+	Should             []Query `json:"should,omitempty"`
+	MustNot            []Query `json:"must_not,omitempty"`
+	MinimumShouldMatch string  `json:"minimum_should_match,omitempty"`
+}
+
+// IDsQuery is the query matching documents by their IDs.
+type IDsQuery struct {
+	Values []string `json:"values"`
+}
+
+// ExistsQuery is the query matching documents with an indexed value
+// for the given field.
+type ExistsQuery struct {
+	Field string `json:"field"`
+}
+
+// TermQuery is the query matching documents with the exact given value
+// for a field. It must not be used on text fields, whose values are analyzed.
+type TermQuery struct {
+	Field string
+	Value interface{}
+}
+
+// MarshalJSON returns the query keyed by its field, as expected
+// by Elasticsearch:
+//
+//	{"relation":{"value":"book"}}
+func (q TermQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		q.Field: map[string]interface{}{"value": q.Value},
+	})
+}
@@ -0,0 +1,98 @@
+// This file regroups all entities and methods to check the health of
+// Elasticseach, namely Ping and Cluster Health APIs.
+
+package golastic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+// ClusterAPI is used to check the health of the Elasticsearch cluster.
+type ClusterAPI struct {
+	client      *elasticsearch.Client
+	instruments instruments
+}
+
+// WithContext sets the context of the requests.
+func (api *ClusterAPI) WithContext(ctx context.Context) *ClusterAPI {
+	api.instruments.ctx = ctx
+	return api
+}
+
+// WithInstrumentation notifies i around each API call.
+func (api *ClusterAPI) WithInstrumentation(i Instrumentation) *ClusterAPI {
+	api.instruments.instrumentation = i
+	return api
+}
+
+// HealthStatus is the health status of a cluster.
+type HealthStatus string
+
+// Cluster health statuses.
+const (
+	// HealthGreen means that all shards are allocated.
+	HealthGreen HealthStatus = "green"
+	// HealthYellow means that all primary shards are allocated,
+	// but not all replicas.
+	HealthYellow HealthStatus = "yellow"
+	// HealthRed means that some primary shards are not allocated.
+	HealthRed HealthStatus = "red"
+)
+
+// ClusterHealth is the health of an Elasticsearch cluster.
+type ClusterHealth struct {
+	ClusterName         string       `json:"cluster_name"`
+	Status              HealthStatus `json:"status"`
+	NumberOfNodes       int          `json:"number_of_nodes"`
+	NumberOfDataNodes   int          `json:"number_of_data_nodes"`
+	ActivePrimaryShards int          `json:"active_primary_shards"`
+	ActiveShards        int          `json:"active_shards"`
+	RelocatingShards    int          `json:"relocating_shards"`
+	InitializingShards  int          `json:"initializing_shards"`
+	UnassignedShards    int          `json:"unassigned_shards"`
+}
+
+// Ping returns nil if the cluster is reachable, an error wrapping
+// ErrUnavailable if it is not.
+func (api ClusterAPI) Ping() (err error) {
+	c := api.instruments.start("cluster.ping", "")
+	defer func() { c.end(err) }()
+
+	res, err := api.client.Ping(api.client.Ping.WithContext(c.ctx))
+	c.response(res)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+	defer res.Body.Close()
+
+	return readErrorResponse(res)
+}
+
+// Health returns the health of the cluster. It returns an error
+// wrapping ErrUnavailable if the cluster is not reachable.
+func (api ClusterAPI) Health() (_ *ClusterHealth, err error) {
+	c := api.instruments.start("cluster.health", "")
+	defer func() { c.end(err) }()
+
+	res, err := api.client.Cluster.Health(api.client.Cluster.Health.WithContext(c.ctx))
+	c.response(res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+	defer res.Body.Close()
+
+	if err := readErrorResponse(res); err != nil {
+		return nil, err
+	}
+
+	var h ClusterHealth
+	if err := json.NewDecoder(res.Body).Decode(&h); err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
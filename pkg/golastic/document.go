@@ -12,25 +12,61 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"sync"
 
 	"github.com/clarketm/json"
 	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
 	"github.com/elastic/go-elasticsearch/v7/esutil"
 )
 
 // DocumentAPI is used to interact with documents in Elasticsearch.
 type DocumentAPI struct {
-	client *elasticsearch.Client
-	index  string
+	client      *elasticsearch.Client
+	index       string
+	fetch       FetchOptions
+	routing     string
+	instruments instruments
+}
+
+// WithRouting routes the requests to the shard of the given routing value
+// instead of the document ID. Child documents of a join field must be
+// routed with the ID of their parent.
+func (api *DocumentAPI) WithRouting(routing string) *DocumentAPI {
+	api.routing = routing
+	return api
+}
+
+// WithSource restricts the returned _source to the fields matching includes
+// and not matching excludes. Both accept wildcards.
+func (api *DocumentAPI) WithSource(includes, excludes []string) *DocumentAPI {
+	api.fetch.Source = &SourceFilter{Includes: includes, Excludes: excludes}
+	return api
+}
+
+// WithStoredFields sets the stored fields returned in the hit's Fields.
+func (api *DocumentAPI) WithStoredFields(fields ...string) *DocumentAPI {
+	api.fetch.StoredFields = fields
+	return api
 }
 
 // -- Get API
 
 // Get returns the result of a getting a document in Elasticsearch.
-func (api *DocumentAPI) Get(id string) (*GetResult, error) {
-	res, err := api.client.Get(api.index, id)
+func (api *DocumentAPI) Get(id string) (_ *GetResult, err error) {
+	c := api.instruments.start("document.get", api.index)
+	defer func() { c.end(err) }()
+
+	opts := append(api.fetch.getOptions(api.client.Get), api.client.Get.WithContext(c.ctx))
+	if api.routing != "" {
+		opts = append(opts, api.client.Get.WithRouting(api.routing))
+	}
+
+	res, err := api.client.Get(api.index, id, opts...)
+	c.response(res)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrUnhandled, err)
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
 	}
 
 	defer res.Body.Close()
@@ -71,7 +107,11 @@ func (r *GetResult) Unwrap(doc Unmarshaler) (interface{}, error) {
 // -- Update API
 
 // Update returns the result of updating a document in Elasticsearch.
-func (api *DocumentAPI) Update(id string, doc interface{}) error {
+func (api *DocumentAPI) Update(id string, doc interface{}) (err error) {
+	c := api.instruments.start("document.update", api.index)
+	defer func() { c.end(err) }()
+	c.op.DocCount = 1
+
 	// Elasticsearch expects the document to be wrapped inside
 	// an object with "doc" key.
 	payload, err := json.Marshal(map[string]interface{}{"doc": doc})
@@ -79,27 +119,154 @@ func (api *DocumentAPI) Update(id string, doc interface{}) error {
 		return fmt.Errorf("%w: %s", ErrUnhandled, err)
 	}
 
-	res, err := api.client.Update(api.index, id, bytes.NewReader(payload))
+	res, err := api.client.Update(api.index, id, bytes.NewReader(payload), api.updateOptions(c.ctx)...)
+	c.response(res)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	return readErrorResponse(res)
+}
+
+// UpdateByScript updates a document in Elasticsearch with the given script.
+// The script can access the document source with "ctx._source".
+func (api *DocumentAPI) UpdateByScript(id string, script Script) (err error) {
+	c := api.instruments.start("document.update_by_script", api.index)
+	defer func() { c.end(err) }()
+	c.op.DocCount = 1
+
+	payload, err := json.Marshal(map[string]interface{}{"script": script})
 	if err != nil {
 		return fmt.Errorf("%w: %s", ErrUnhandled, err)
 	}
 
+	res, err := api.client.Update(api.index, id, bytes.NewReader(payload), api.updateOptions(c.ctx)...)
+	c.response(res)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
 	defer res.Body.Close()
 	return readErrorResponse(res)
 }
 
+// UpdateByQuery updates the documents matching the given query with the
+// given script, and returns the number of updated documents. Documents
+// modified concurrently are skipped rather than failing the update.
+func (api *DocumentAPI) UpdateByQuery(q SearchQuery, script Script) (_ int, err error) {
+	c := api.instruments.start("document.update_by_query", api.index)
+	defer func() { c.end(err) }()
+
+	payload, err := json.Marshal(map[string]interface{}{"query": q.Query, "script": script})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrUnhandled, err)
+	}
+
+	opts := []func(*esapi.UpdateByQueryRequest){
+		api.client.UpdateByQuery.WithContext(c.ctx),
+		api.client.UpdateByQuery.WithBody(bytes.NewReader(payload)),
+		api.client.UpdateByQuery.WithConflicts("proceed"),
+	}
+	if api.routing != "" {
+		opts = append(opts, api.client.UpdateByQuery.WithRouting(api.routing))
+	}
+
+	res, err := api.client.UpdateByQuery([]string{api.index}, opts...)
+	c.response(res)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	if err := readErrorResponse(res); err != nil {
+		return 0, err
+	}
+
+	var r struct {
+		Updated int `json:"updated"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, err
+	}
+	c.op.DocCount = r.Updated
+
+	return r.Updated, nil
+}
+
+// DeleteByQuery deletes the documents matching the given query, and returns
+// the number of deleted documents. Documents modified concurrently are
+// skipped rather than failing the deletion.
+func (api *DocumentAPI) DeleteByQuery(q SearchQuery) (_ int, err error) {
+	c := api.instruments.start("document.delete_by_query", api.index)
+	defer func() { c.end(err) }()
+
+	payload, err := json.Marshal(map[string]interface{}{"query": q.Query})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrUnhandled, err)
+	}
+
+	opts := []func(*esapi.DeleteByQueryRequest){
+		api.client.DeleteByQuery.WithContext(c.ctx),
+		api.client.DeleteByQuery.WithConflicts("proceed"),
+	}
+	if api.routing != "" {
+		opts = append(opts, api.client.DeleteByQuery.WithRouting(api.routing))
+	}
+
+	res, err := api.client.DeleteByQuery([]string{api.index}, bytes.NewReader(payload), opts...)
+	c.response(res)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	if err := readErrorResponse(res); err != nil {
+		return 0, err
+	}
+
+	var r struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, err
+	}
+	c.op.DocCount = r.Deleted
+
+	return r.Deleted, nil
+}
+
+// updateOptions returns the options shared by update requests.
+func (api *DocumentAPI) updateOptions(ctx context.Context) []func(*esapi.UpdateRequest) {
+	opts := []func(*esapi.UpdateRequest){api.client.Update.WithContext(ctx)}
+	if api.routing != "" {
+		opts = append(opts, api.client.Update.WithRouting(api.routing))
+	}
+	return opts
+}
+
 // -- Index API
 
 // Update returns the result of a indexing a document in Elasticsearch.
-func (api *DocumentAPI) Index(doc interface{}) (*IndexResult, error) {
+func (api *DocumentAPI) Index(doc interface{}) (_ *IndexResult, err error) {
+	c := api.instruments.start("document.index", api.index)
+	defer func() { c.end(err) }()
+	c.op.DocCount = 1
+
 	payload, err := json.Marshal(doc)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrUnhandled, err)
 	}
 
-	res, err := api.client.Index(api.index, bytes.NewReader(payload))
+	opts := []func(*esapi.IndexRequest){api.client.Index.WithContext(c.ctx)}
+	if api.routing != "" {
+		opts = append(opts, api.client.Index.WithRouting(api.routing))
+	}
+
+	res, err := api.client.Index(api.index, bytes.NewReader(payload), opts...)
+	c.response(res)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrUnhandled, err)
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
 	}
 
 	defer res.Body.Close()
@@ -136,10 +303,20 @@ func (r *IndexResult) Unwrap() (string, error) {
 // -- Delete API
 
 // Update returns the result of a deleting a document in Elasticsearch.
-func (api *DocumentAPI) Delete(id string) error {
-	res, err := api.client.Delete(api.index, id)
+func (api *DocumentAPI) Delete(id string) (err error) {
+	c := api.instruments.start("document.delete", api.index)
+	defer func() { c.end(err) }()
+	c.op.DocCount = 1
+
+	opts := []func(*esapi.DeleteRequest){api.client.Delete.WithContext(c.ctx)}
+	if api.routing != "" {
+		opts = append(opts, api.client.Delete.WithRouting(api.routing))
+	}
+
+	res, err := api.client.Delete(api.index, id, opts...)
+	c.response(res)
 	if err != nil {
-		return fmt.Errorf("%w: %s", ErrUnhandled, err)
+		return fmt.Errorf("%w: %s", ErrUnavailable, err)
 	}
 
 	defer res.Body.Close()
@@ -149,39 +326,134 @@ func (api *DocumentAPI) Delete(id string) error {
 // -- Bulk API
 
 // Update returns the result of a indexing many documents in Elasticsearch.
-func (api *DocumentAPI) Bulk(docs []interface{}) error {
-	bi, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
-		Index:  api.index,
-		Client: api.client,
-	})
+// Documents rejected with a 429 Too Many Requests are sent again in a new
+// bulk, up to bulkMaxRetries times.
+func (api *DocumentAPI) Bulk(docs []interface{}) (err error) {
+	c := api.instruments.start("document.bulk", api.index)
+	defer func() { c.end(err) }()
+	c.op.DocCount = len(docs)
+	c.op.Bulk = &BulkStats{}
+
+	items := make([]bulkItem, len(docs))
+	for i, doc := range docs {
+		items[i].action = "index"
+		if items[i].payload, err = json.Marshal(doc); err != nil {
+			return err
+		}
+	}
+
+	if err := api.bulkWithRetries(c, items); err != nil {
+		return err
+	}
+
+	if c.op.Bulk.Failed > 0 {
+		log.Printf("indexed [%d] documents with [%d] errors", c.op.Bulk.Flushed, c.op.Bulk.Failed)
+	} else {
+		log.Printf("Successfully indexed [%d] documents", c.op.Bulk.Flushed)
+	}
+
+	return nil
+}
+
+// BulkUpdateByScript updates the documents of the given IDs with the same
+// script in a single bulk. As with Bulk, rejected updates are retried and
+// failed updates are logged.
+func (api *DocumentAPI) BulkUpdateByScript(ids []string, script Script) (err error) {
+	c := api.instruments.start("document.bulk_update_by_script", api.index)
+	defer func() { c.end(err) }()
+	c.op.DocCount = len(ids)
+	c.op.Bulk = &BulkStats{}
+
+	payload, err := json.Marshal(map[string]interface{}{"script": script})
 	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnhandled, err)
+	}
+
+	items := make([]bulkItem, len(ids))
+	for i, id := range ids {
+		items[i] = bulkItem{action: "update", id: id, payload: payload}
+	}
+
+	if err := api.bulkWithRetries(c, items); err != nil {
 		return err
 	}
-	defer bi.Close(context.Background())
 
-	for _, doc := range docs {
-		payload, err := json.Marshal(doc)
-		if err != nil {
+	if c.op.Bulk.Failed > 0 {
+		log.Printf("updated [%d] documents with [%d] errors", c.op.Bulk.Flushed, c.op.Bulk.Failed)
+	}
+
+	return nil
+}
+
+// bulkMaxRetries is the maximum number of times the documents rejected
+// by a bulk are sent again.
+const bulkMaxRetries = 3
+
+// bulkItem is an action of a bulk on a document.
+type bulkItem struct {
+	action  string
+	id      string // Generated by Elasticsearch if empty.
+	payload []byte
+}
+
+// bulkWithRetries performs the bulk actions, sending again the ones
+// rejected with a 429 Too Many Requests up to bulkMaxRetries times.
+func (api *DocumentAPI) bulkWithRetries(c *call, items []bulkItem) (err error) {
+	for attempt := 0; len(items) > 0; attempt++ {
+		if attempt > 0 {
+			c.op.Bulk.Retried += uint64(len(items))
+		}
+		if items, err = api.bulk(c, items, attempt < bulkMaxRetries); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		if err := bi.Add(context.Background(), esutil.BulkIndexerItem{
-			Action: "index",
-			Body:   bytes.NewReader(payload),
-			OnFailure: func(_ context.Context, _ esutil.BulkIndexerItem, _ esutil.BulkIndexerResponseItem, e error) {
-				log.Printf("failed to index document %#v: %s", doc, e)
+// bulk performs the actions and records the outcome in the stats of
+// the call. If retry is true, it returns the actions rejected with a
+// 429 Too Many Requests instead of counting them as failed.
+func (api *DocumentAPI) bulk(c *call, items []bulkItem, retry bool) ([]bulkItem, error) {
+	bi, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Index:  api.index,
+		Client: api.client,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var rejected []bulkItem
+	for _, item := range items {
+		item := item
+		if err := bi.Add(c.ctx, esutil.BulkIndexerItem{
+			Action:     item.action,
+			DocumentID: item.id,
+			Body:       bytes.NewReader(item.payload),
+			OnFailure: func(_ context.Context, _ esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, e error) {
+				if retry && e == nil && res.Status == http.StatusTooManyRequests {
+					mu.Lock()
+					rejected = append(rejected, item)
+					mu.Unlock()
+					return
+				}
+				if e == nil {
+					e = fmt.Errorf("%s: %s", res.Error.Type, res.Error.Reason)
+				}
+				log.Printf("failed to %s document %s: %s", item.action, item.payload, e)
 			},
 		}); err != nil {
-			return err
+			bi.Close(c.ctx) //nolint:errcheck // the error of Add is returned
+			return nil, err
 		}
 	}
 
-	stats := bi.Stats()
-	if stats.NumFailed > 0 {
-		log.Printf("indexed [%d] documents with [%d] errors", stats.NumFlushed, stats.NumFailed)
-	} else {
-		log.Printf("Successfully indexed [%d] documents", stats.NumFlushed)
+	if err := bi.Close(c.ctx); err != nil {
+		return nil, err
 	}
 
-	return nil
+	stats := bi.Stats()
+	c.op.Bulk.Flushed += stats.NumFlushed
+	c.op.Bulk.Failed += stats.NumFailed - uint64(len(rejected))
+	return rejected, nil
 }
@@ -12,6 +12,15 @@ var (
 	// ErrNotFound is returned when a requested resource is not found.
 	ErrNotFound = errors.New("resource not found")
 
+	// ErrConflict is returned when a write conflicts with the current
+	// state of a resource, e.g. a document version conflict.
+	ErrConflict = errors.New("resource conflict")
+
+	// ErrUnavailable is returned when Elasticsearch is temporarily unable
+	// to handle a request, e.g. when it is overloaded, down or does not
+	// respond in time.
+	ErrUnavailable = errors.New("elasticsearch unavailable")
+
 	// ErrUnhandled is returned when an encountered error cannot be identified.
 	ErrUnhandled = errors.New("elasticsearch unhandled error")
 )
@@ -19,7 +28,12 @@ var (
 var statusErrorMapping = map[int]error{
 	http.StatusBadRequest:          ErrBadRequest,
 	http.StatusNotFound:            ErrNotFound,
+	http.StatusConflict:            ErrConflict,
 	http.StatusInternalServerError: ErrUnhandled,
+	http.StatusTooManyRequests:     ErrUnavailable,
+	http.StatusBadGateway:          ErrUnavailable,
+	http.StatusServiceUnavailable:  ErrUnavailable,
+	http.StatusGatewayTimeout:      ErrUnavailable,
 }
 
 func statusError(code int) error {
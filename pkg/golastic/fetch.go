@@ -0,0 +1,42 @@
+// This file regroups the options controlling which parts of a document
+// are returned by Elasticsearch Search and Get APIs.
+
+package golastic
+
+import "github.com/elastic/go-elasticsearch/v7/esapi"
+
+// SourceFilter restricts the fields of the _source returned for a document.
+// Patterns support wildcards, e.g. "author.*".
+type SourceFilter struct {
+	Includes []string `json:"includes,omitempty"`
+	Excludes []string `json:"excludes,omitempty"`
+}
+
+// FetchOptions configures which parts of the matching documents are
+// returned. The zero value returns the full _source only.
+//
+// StoredFields, DocvalueFields and Fields values are surfaced in Hit.Fields.
+type FetchOptions struct {
+	Source         *SourceFilter
+	StoredFields   []string
+	DocvalueFields []string
+	Fields         []string // Only supported by the Search API.
+}
+
+// getOptions returns the request options matching the fetch options.
+// DocvalueFields and Fields are ignored as the Get API does not support them.
+func (o FetchOptions) getOptions(g esapi.Get) []func(*esapi.GetRequest) {
+	var opts []func(*esapi.GetRequest)
+	if o.Source != nil {
+		if len(o.Source.Includes) != 0 {
+			opts = append(opts, g.WithSourceIncludes(o.Source.Includes...))
+		}
+		if len(o.Source.Excludes) != 0 {
+			opts = append(opts, g.WithSourceExcludes(o.Source.Excludes...))
+		}
+	}
+	if len(o.StoredFields) != 0 {
+		opts = append(opts, g.WithStoredFields(o.StoredFields...))
+	}
+	return opts
+}
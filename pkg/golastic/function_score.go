@@ -0,0 +1,137 @@
+// This file regroups the entities used to build function_score queries,
+// which modify the score of the documents retrieved by a query.
+
+package golastic
+
+import "github.com/clarketm/json"
+
+// Score modes define how the scores of the functions are combined.
+const (
+	ScoreModeMultiply = "multiply"
+	ScoreModeSum      = "sum"
+	ScoreModeAvg      = "avg"
+	ScoreModeFirst    = "first"
+	ScoreModeMax      = "max"
+	ScoreModeMin      = "min"
+)
+
+// Boost modes define how the combined score of the functions is combined
+// with the score of the query.
+const (
+	BoostModeMultiply = "multiply"
+	BoostModeReplace  = "replace"
+	BoostModeSum      = "sum"
+	BoostModeAvg      = "avg"
+	BoostModeMax      = "max"
+	BoostModeMin      = "min"
+)
+
+// FunctionScoreQuery is the query for modifying the score of the documents
+// retrieved by a query with one or more score functions.
+type FunctionScoreQuery struct {
+	Query     *Query          `json:"query,omitempty"` // Defaults to match_all.
+	Functions []ScoreFunction `json:"functions,omitempty"`
+	ScoreMode string          `json:"score_mode,omitempty"`
+	BoostMode string          `json:"boost_mode,omitempty"`
+	MaxBoost  float64         `json:"max_boost,omitempty"`
+	MinScore  float64         `json:"min_score,omitempty"`
+	Boost     float64         `json:"boost,omitempty"`
+}
+
+// ScoreFunction is a single function of a function_score query.
+// The function applies to the documents matching Filter if set.
+//
+// Along with Filter and Weight, only one of its fields must be
+// used at a time. A function with only a Weight returns the weight
+// as a constant score.
+type ScoreFunction struct {
+	Filter *Query  `json:"filter,omitempty"`
+	Weight float64 `json:"weight,omitempty"`
+
+	FieldValueFactor *FieldValueFactorFunction `json:"field_value_factor,omitempty"`
+	Gauss            *DecayFunction            `json:"gauss,omitempty"`
+	Exp              *DecayFunction            `json:"exp,omitempty"`
+	Linear           *DecayFunction            `json:"linear,omitempty"`
+	RandomScore      *RandomScoreFunction      `json:"random_score,omitempty"`
+	ScriptScore      *ScriptScoreFunction      `json:"script_score,omitempty"`
+}
+
+// FieldValueFactorFunction computes a score from the value of a field.
+type FieldValueFactorFunction struct {
+	Field    string   `json:"field"`
+	Factor   float64  `json:"factor,omitempty"`
+	Modifier string   `json:"modifier,omitempty"` // For instance "log1p" or "sqrt".
+	Missing  *float64 `json:"missing,omitempty"`  // Value used for documents without the field.
+}
+
+// DecayFunction scores a document depending on the distance of a numeric,
+// date or geo field value from a given origin.
+//
+// For dates, Origin is a date or "now" and Scale and Offset are
+// durations such as "10d".
+type DecayFunction struct {
+	Field          string
+	Origin         interface{}
+	Scale          interface{}
+	Offset         interface{}
+	Decay          float64
+	MultiValueMode string // For instance "min", "max" or "avg".
+}
+
+// MarshalJSON returns the decay function formatted as expected
+// by Elasticsearch, the parameters being nested under the field name.
+//
+// For instance, marshaling the following:
+//
+//	DecayFunction{Field: "created_at", Origin: "now", Scale: "30d"}
+//
+// gives:
+//
+//	{"created_at":{"origin":"now","scale":"30d"}}
+func (f DecayFunction) MarshalJSON() ([]byte, error) {
+	type params struct {
+		Origin interface{} `json:"origin,omitempty"`
+		Scale  interface{} `json:"scale"`
+		Offset interface{} `json:"offset,omitempty"`
+		Decay  float64     `json:"decay,omitempty"`
+	}
+
+	m := map[string]interface{}{
+		f.Field: params{
+			Origin: f.Origin,
+			Scale:  f.Scale,
+			Offset: f.Offset,
+			Decay:  f.Decay,
+		},
+	}
+	if f.MultiValueMode != "" {
+		m["multi_value_mode"] = f.MultiValueMode
+	}
+	return json.Marshal(m)
+}
+
+// RandomScoreFunction generates scores uniformly distributed from 0 to 1.
+// Scores are reproducible when Seed and Field are both set, Field being
+// a field with unique values per document such as "_seq_no".
+type RandomScoreFunction struct {
+	Seed  int64  `json:"seed,omitempty"`
+	Field string `json:"field,omitempty"`
+}
+
+// ScriptScoreFunction computes a score with a script.
+// The script can access the query score with "_score".
+type ScriptScoreFunction struct {
+	Script Script `json:"script"`
+}
+
+// NewFunctionScoreQuery returns a configured SearchQuery modifying
+// the score of the documents retrieved by q with the given functions.
+func NewFunctionScoreQuery(q SearchQuery, functions ...ScoreFunction) SearchQuery {
+	inner := q.Query
+	fq := SearchQuery{Fields: q.Fields}
+	fq.Query.FunctionScore = &FunctionScoreQuery{
+		Query:     &inner,
+		Functions: functions,
+	}
+	return fq
+}
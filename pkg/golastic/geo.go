@@ -0,0 +1,176 @@
+// This file regroups the entities used to query, sort and aggregate
+// documents by the location of geo_point and geo_shape fields.
+
+package golastic
+
+import "github.com/clarketm/json"
+
+// Shape relations define how the shape of a GeoShapeQuery is matched
+// against the shapes of the documents.
+const (
+	ShapeRelationIntersects = "intersects"
+	ShapeRelationDisjoint   = "disjoint"
+	ShapeRelationWithin     = "within"
+	ShapeRelationContains   = "contains"
+)
+
+// GeoPoint is a geographic point, as stored in a geo_point field.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// GeoShape is a GeoJSON geometry, as stored in a geo_shape field.
+// For instance:
+//
+//	GeoShape{Type: "envelope", Coordinates: [][]float64{{2.2, 48.9}, {2.4, 48.8}}}
+type GeoShape struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"` // Longitude first.
+}
+
+// GeoDistanceQuery is the query matching documents with a geo_point
+// within Distance of Origin, e.g. "5km".
+type GeoDistanceQuery struct {
+	Field        string
+	Origin       GeoPoint
+	Distance     string
+	DistanceType string // Either "arc" (default) or "plane".
+}
+
+// MarshalJSON returns the query shaped as expected by Elasticsearch:
+//
+//	{"distance":"5km","location":{"lat":48.85,"lon":2.35}}
+func (q GeoDistanceQuery) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{
+		q.Field:    q.Origin,
+		"distance": q.Distance,
+	}
+	if q.DistanceType != "" {
+		m["distance_type"] = q.DistanceType
+	}
+	return json.Marshal(m)
+}
+
+// GeoBoundingBoxQuery is the query matching documents with a geo_point
+// within the bounding box defined by its top left and bottom right corners.
+type GeoBoundingBoxQuery struct {
+	Field       string
+	TopLeft     GeoPoint
+	BottomRight GeoPoint
+}
+
+// MarshalJSON returns the query keyed by its field, as expected
+// by Elasticsearch.
+func (q GeoBoundingBoxQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		q.Field: map[string]GeoPoint{
+			"top_left":     q.TopLeft,
+			"bottom_right": q.BottomRight,
+		},
+	})
+}
+
+// GeoPolygonQuery is the query matching documents with a geo_point
+// within the polygon defined by Points.
+type GeoPolygonQuery struct {
+	Field  string
+	Points []GeoPoint
+}
+
+// MarshalJSON returns the query keyed by its field, as expected
+// by Elasticsearch.
+func (q GeoPolygonQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		q.Field: map[string][]GeoPoint{"points": q.Points},
+	})
+}
+
+// GeoShapeQuery is the query matching documents with a geo_shape
+// or geo_point related to Shape as defined by Relation.
+type GeoShapeQuery struct {
+	Field    string
+	Shape    GeoShape
+	Relation string // Defaults to ShapeRelationIntersects.
+}
+
+// MarshalJSON returns the query keyed by its field, as expected
+// by Elasticsearch.
+func (q GeoShapeQuery) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{"shape": q.Shape}
+	if q.Relation != "" {
+		m["relation"] = q.Relation
+	}
+	return json.Marshal(map[string]interface{}{q.Field: m})
+}
+
+// NewGeoDistanceQuery returns a configured SearchQuery matching the
+// documents with a geo_point field within distance of origin.
+func NewGeoDistanceQuery(field string, origin GeoPoint, distance string) SearchQuery {
+	q := SearchQuery{}
+	q.Query.GeoDistance = &GeoDistanceQuery{
+		Field:    field,
+		Origin:   origin,
+		Distance: distance,
+	}
+	return q
+}
+
+// GeoDistanceSort sorts the hits by the distance of a geo_point field
+// to Origin. The distance of each hit is returned in Hit.Sort, in Unit.
+type GeoDistanceSort struct {
+	Field        string
+	Origin       GeoPoint
+	Order        string // Defaults to "asc".
+	Unit         string // Defaults to "m".
+	Mode         string // For multi-valued fields, defaults to "min".
+	DistanceType string // Either "arc" (default) or "plane".
+}
+
+// MarshalJSON returns the sort shaped as expected by Elasticsearch:
+//
+//	{"_geo_distance":{"location":{"lat":48.85,"lon":2.35},"unit":"km"}}
+func (s GeoDistanceSort) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{s.Field: s.Origin}
+	for k, v := range map[string]string{
+		"order":         s.Order,
+		"unit":          s.Unit,
+		"mode":          s.Mode,
+		"distance_type": s.DistanceType,
+	} {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	return json.Marshal(map[string]interface{}{"_geo_distance": m})
+}
+
+// GeoDistanceAggregation is the bucket aggregation grouping the documents
+// by ranges of distance of a geo_point field to Origin, in Unit.
+type GeoDistanceAggregation struct {
+	Field        string             `json:"field"`
+	Origin       GeoPoint           `json:"origin"`
+	Unit         string             `json:"unit,omitempty"` // Defaults to "m".
+	DistanceType string             `json:"distance_type,omitempty"`
+	Ranges       []AggregationRange `json:"ranges"`
+}
+
+// AggregationRange is a range of a range aggregation. From is inclusive
+// and To is exclusive. The range is unbounded on nil sides.
+type AggregationRange struct {
+	Key  string   `json:"key,omitempty"`
+	From *float64 `json:"from,omitempty"`
+	To   *float64 `json:"to,omitempty"`
+}
+
+// GeoPointMapping returns the given index mapping extended with
+// a geo_point field.
+func GeoPointMapping(mapping, field string) (string, error) {
+	return extendMapping(mapping, field, map[string]interface{}{"type": "geo_point"})
+}
+
+// GeoShapeMapping returns the given index mapping extended with
+// a geo_shape field.
+func GeoShapeMapping(mapping, field string) (string, error) {
+	return extendMapping(mapping, field, map[string]interface{}{"type": "geo_shape"})
+}
@@ -0,0 +1,109 @@
+package golastic_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+var paris = golastic.GeoPoint{Lat: 48.85, Lon: 2.35}
+
+func TestMarshalingGeo(t *testing.T) {
+	cases := []struct {
+		name string
+		q    golastic.Query
+		exp  string
+	}{
+		{
+			name: "geo_distance",
+			q:    golastic.NewGeoDistanceQuery("location", paris, "5km").Query,
+			exp:  `{"geo_distance":{"distance":"5km","location":{"lat":48.85,"lon":2.35}}}`,
+		},
+		{
+			name: "geo_bounding_box",
+			q: golastic.Query{GeoBoundingBox: &golastic.GeoBoundingBoxQuery{
+				Field:       "location",
+				TopLeft:     golastic.GeoPoint{Lat: 49, Lon: 2},
+				BottomRight: golastic.GeoPoint{Lat: 48, Lon: 3},
+			}},
+			exp: `{"geo_bounding_box":{"location":{"bottom_right":{"lat":48,"lon":3},"top_left":{"lat":49,"lon":2}}}}`,
+		},
+		{
+			name: "geo_polygon",
+			q: golastic.Query{GeoPolygon: &golastic.GeoPolygonQuery{
+				Field:  "location",
+				Points: []golastic.GeoPoint{{Lat: 49, Lon: 2}, {Lat: 48, Lon: 2}, {Lat: 48, Lon: 3}},
+			}},
+			exp: `{"geo_polygon":{"location":{"points":[{"lat":49,"lon":2},{"lat":48,"lon":2},{"lat":48,"lon":3}]}}}`,
+		},
+		{
+			name: "geo_shape",
+			q: golastic.Query{GeoShape: &golastic.GeoShapeQuery{
+				Field:    "area",
+				Shape:    golastic.GeoShape{Type: "envelope", Coordinates: [][]float64{{2, 49}, {3, 48}}},
+				Relation: golastic.ShapeRelationWithin,
+			}},
+			exp: `{"geo_shape":{"area":{"relation":"within","shape":{"type":"envelope","coordinates":[[2,49],[3,48]]}}}}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := golastic.SearchQuery{Query: tc.q}.String()
+			if exp := `{"query":` + tc.exp + `}`; got != exp {
+				t.Errorf("unexpected marshaling output: expected %s, got %s", exp, got)
+			}
+		})
+	}
+}
+
+func TestGeoDistanceSortAndAggregation(t *testing.T) {
+	var body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"hits":{"total":{"value":1,"relation":"eq"},"hits":[
+			{"_id":"1","_source":{},"sort":[1.2,3]}
+		]},"aggregations":{"rings":{"buckets":[
+			{"key":"*-1.0","to":1.0,"doc_count":0},
+			{"key":"1.0-5.0","from":1.0,"to":5.0,"doc_count":1}
+		]}}}`))
+	})
+
+	one, five := 1.0, 5.0
+	res, err := golastic.Search(ctx).
+		WithAggregations(map[string]golastic.Aggregation{
+			"rings": {GeoDistance: &golastic.GeoDistanceAggregation{
+				Field:  "location",
+				Origin: paris,
+				Unit:   "km",
+				Ranges: []golastic.AggregationRange{{To: &one}, {From: &one, To: &five}},
+			}},
+		}).
+		Query(
+			golastic.NewGeoDistanceQuery("location", paris, "5km"),
+			golastic.SearchPagination{Size: 10},
+			golastic.SearchSort{golastic.SortByDistance("location", paris).WithUnit("km"), golastic.SortByDoc()},
+		)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := `{"query":{"geo_distance":{"distance":"5km","location":{"lat":48.85,"lon":2.35}}},"from":0,"size":10,` +
+		`"sort":[{"_geo_distance":{"location":{"lat":48.85,"lon":2.35},"unit":"km"}},{"_doc":"asc"}],` +
+		`"aggs":{"rings":{"geo_distance":{"field":"location","origin":{"lat":48.85,"lon":2.35},"unit":"km",` +
+		`"ranges":[{"to":1},{"from":1,"to":5}]}}}}`
+	if body != exp {
+		t.Errorf("unexpected body: expected %s, got %s", exp, body)
+	}
+
+	if d := res.Hits.Hits[0].Sort[0]; d != 1.2 {
+		t.Errorf("unexpected distance: expected 1.2, got %v", d)
+	}
+	b := res.Aggregations["rings"].Buckets[1]
+	if b.From == nil || *b.From != 1 || b.To == nil || *b.To != 5 || b.DocCount != 1 {
+		t.Errorf("unexpected bucket: %#v", b)
+	}
+}
@@ -0,0 +1,74 @@
+// Package golasticotel provides a golastic.Instrumentation recording
+// the API calls as OpenTelemetry spans.
+//
+// The spans are children of the span of the context of the calls, set with
+// golastic.ContextConfig.Context, so that a call can be traced from the HTTP
+// handler performing it. Used along with golastic.InstrumentationLogger, each
+// HTTP round trip to Elasticsearch is recorded as a child span of its call.
+package golasticotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// instrumentationName identifies the tracer of the spans.
+const instrumentationName = "github.com/moreirathomas/golastic/pkg/golastic"
+
+// Attributes of the spans specific to golastic.
+const (
+	IndexKey    = attribute.Key("golastic.index")
+	DocCountKey = attribute.Key("golastic.doc_count")
+)
+
+// Instrumentation records each golastic API call as a span named after
+// the operation, e.g. "golastic.search".
+type Instrumentation struct {
+	tracer trace.Tracer
+}
+
+var _ golastic.Instrumentation = (*Instrumentation)(nil)
+
+// New returns an Instrumentation creating spans with the given provider,
+// or with the global provider if nil.
+func New(tp trace.TracerProvider) *Instrumentation {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Instrumentation{tracer: tp.Tracer(instrumentationName)}
+}
+
+// Start starts the span of the operation.
+func (i *Instrumentation) Start(ctx context.Context, op *golastic.Operation) context.Context {
+	ctx, _ = i.tracer.Start(ctx, "golastic."+op.Name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(op.StartTime),
+		trace.WithAttributes(
+			semconv.DBSystemElasticsearch,
+			semconv.DBOperationKey.String(op.Name),
+			IndexKey.String(op.Index),
+		),
+	)
+	return ctx
+}
+
+// End ends the span of the operation with its outcome.
+func (i *Instrumentation) End(ctx context.Context, op *golastic.Operation) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(DocCountKey.Int(op.DocCount))
+	if op.StatusCode != 0 {
+		span.SetAttributes(semconv.HTTPStatusCode(op.StatusCode))
+	}
+	if op.Err != nil {
+		span.RecordError(op.Err)
+		span.SetStatus(codes.Error, op.Err.Error())
+	}
+	span.End(trace.WithTimestamp(op.StartTime.Add(op.Duration)))
+}
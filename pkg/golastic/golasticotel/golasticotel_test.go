@@ -0,0 +1,81 @@
+package golasticotel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+	"github.com/moreirathomas/golastic/pkg/golastic/golasticotel"
+	"github.com/moreirathomas/golastic/pkg/golastic/golastictest"
+)
+
+type book struct {
+	Title string `json:"title"`
+}
+
+func TestInstrumentation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	instrumentation := golasticotel.New(tp)
+
+	s := golastictest.NewServer()
+	t.Cleanup(s.Close)
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{s.URL},
+		Logger:    golastic.InstrumentationLogger{Instrumentation: instrumentation},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "GET /books")
+	cfg := golastic.ContextConfig{
+		Client:          client,
+		IndexName:       "books",
+		Context:         ctx,
+		Instrumentation: instrumentation,
+	}
+	if _, err := golastic.DocumentOf[book](cfg).Index(book{Title: "Dune"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := golastic.DocumentOf[book](cfg).Get("missing"); !errors.Is(err, golastic.ErrNotFound) {
+		t.Fatalf("unexpected error: expected %s, got %v", golastic.ErrNotFound, err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	names := []string{}
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	exp := []string{
+		"golastic.http.request", "golastic.document.index",
+		"golastic.http.request", "golastic.document.get",
+		"GET /books",
+	}
+	if len(names) != len(exp) {
+		t.Fatalf("unexpected spans: expected %v, got %v", exp, names)
+	}
+	for i := range exp {
+		if names[i] != exp[i] {
+			t.Fatalf("unexpected spans: expected %v, got %v", exp, names)
+		}
+	}
+
+	// The round trips are children of their call, children of the parent.
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Errorf("unexpected parent of %s: %s", spans[0].Name(), spans[0].Parent().SpanID())
+	}
+	if spans[1].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("unexpected parent of %s: %s", spans[1].Name(), spans[1].Parent().SpanID())
+	}
+	if spans[3].Status().Code != codes.Error {
+		t.Errorf("unexpected status of %s: %v", spans[3].Name(), spans[3].Status())
+	}
+}
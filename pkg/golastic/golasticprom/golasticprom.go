@@ -0,0 +1,74 @@
+// Package golasticprom provides a golastic.Instrumentation recording
+// Prometheus metrics of the API calls: their latency per operation, and
+// the documents of bulks by outcome.
+package golasticprom
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// Instrumentation records the metrics of golastic API calls.
+type Instrumentation struct {
+	duration *prometheus.HistogramVec
+	bulkDocs *prometheus.CounterVec
+}
+
+var _ golastic.Instrumentation = (*Instrumentation)(nil)
+
+// New returns an Instrumentation registering its metrics with reg,
+// or with the default registerer if nil:
+//   - golastic_call_duration_seconds, a histogram labelled with the
+//     operation and the response status, or "error" if none was received;
+//   - golastic_bulk_documents_total, a counter labelled with the index
+//     and the outcome of the documents: "flushed", "failed" or "retried".
+func New(reg prometheus.Registerer) *Instrumentation {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	i := &Instrumentation{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "golastic_call_duration_seconds",
+			Help:    "Duration of the golastic API calls.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation", "status"}),
+		bulkDocs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "golastic_bulk_documents_total",
+			Help: "Documents sent in bulks by outcome.",
+		}, []string{"index", "result"}),
+	}
+	reg.MustRegister(i.duration, i.bulkDocs)
+	return i
+}
+
+// Start does nothing: the operation holds its start time.
+func (i *Instrumentation) Start(ctx context.Context, _ *golastic.Operation) context.Context {
+	return ctx
+}
+
+// End records the metrics of the operation.
+func (i *Instrumentation) End(_ context.Context, op *golastic.Operation) {
+	i.duration.WithLabelValues(op.Name, status(op)).Observe(op.Duration.Seconds())
+
+	if op.Bulk != nil {
+		i.bulkDocs.WithLabelValues(op.Index, "flushed").Add(float64(op.Bulk.Flushed))
+		i.bulkDocs.WithLabelValues(op.Index, "failed").Add(float64(op.Bulk.Failed))
+		i.bulkDocs.WithLabelValues(op.Index, "retried").Add(float64(op.Bulk.Retried))
+	}
+}
+
+// status returns the status label of the operation.
+func status(op *golastic.Operation) string {
+	switch {
+	case op.StatusCode != 0:
+		return strconv.Itoa(op.StatusCode)
+	case op.Err != nil:
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,49 @@
+package golasticprom_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+	"github.com/moreirathomas/golastic/pkg/golastic/golasticprom"
+	"github.com/moreirathomas/golastic/pkg/golastic/golastictest"
+)
+
+type book struct {
+	Title string `json:"title"`
+}
+
+func TestInstrumentation(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cfg := golastic.ContextConfig{
+		Client:          golastictest.NewClient(t),
+		IndexName:       "books",
+		Instrumentation: golasticprom.New(reg),
+	}
+
+	if err := golastic.DocumentOf[book](cfg).Bulk([]book{{Title: "Dune"}, {Title: "Emma"}}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := golastic.DocumentOf[book](cfg).Get("missing"); !errors.Is(err, golastic.ErrNotFound) {
+		t.Fatalf("unexpected error: expected %s, got %v", golastic.ErrNotFound, err)
+	}
+
+	if n := testutil.CollectAndCount(reg, "golastic_call_duration_seconds"); n != 2 {
+		t.Errorf("unexpected number of call series: expected 2, got %d", n)
+	}
+
+	exp := `
+# HELP golastic_bulk_documents_total Documents sent in bulks by outcome.
+# TYPE golastic_bulk_documents_total counter
+golastic_bulk_documents_total{index="books",result="failed"} 0
+golastic_bulk_documents_total{index="books",result="flushed"} 2
+golastic_bulk_documents_total{index="books",result="retried"} 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(exp), "golastic_bulk_documents_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,84 @@
+package golastictest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// bulkAction is the metadata line of an action of a bulk request.
+type bulkAction struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id"`
+}
+
+// bulk performs the actions of the NDJSON body on the given default index.
+// Each action succeeds or fails independently.
+func (s *Server) bulk(w http.ResponseWriter, name string, body []byte) {
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	sc.Buffer(nil, len(body)+1)
+
+	items := []map[string]interface{}{}
+	hasErrors := false
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var meta map[string]bulkAction
+		if err := json.Unmarshal(line, &meta); err != nil || len(meta) != 1 {
+			respondError(w, http.StatusBadRequest, "illegal_argument_exception",
+				fmt.Sprintf("malformed action/metadata line: %s", line))
+			return
+		}
+
+		for op, a := range meta {
+			if a.Index == "" {
+				a.Index = name
+			}
+
+			var status int
+			var res map[string]interface{}
+			switch op {
+			case "index", "create", "update":
+				if !sc.Scan() {
+					respondError(w, http.StatusBadRequest, "illegal_argument_exception",
+						fmt.Sprintf("missing source of action [%s]", op))
+					return
+				}
+				src := append([]byte(nil), sc.Bytes()...)
+				if op == "update" {
+					status, res = s.update(a.Index, a.ID, src)
+				} else {
+					status, res = s.put(a.Index, a.ID, src)
+				}
+			case "delete":
+				status, res = s.delete(a.Index, a.ID)
+			default:
+				respondError(w, http.StatusBadRequest, "illegal_argument_exception",
+					fmt.Sprintf("unknown action [%s]", op))
+				return
+			}
+
+			// Deleting a missing document is not an error.
+			if _, ok := res["error"]; ok {
+				hasErrors = true
+			}
+			res["status"] = status
+			res["_index"] = a.Index
+			if _, ok := res["_id"]; !ok {
+				res["_id"] = a.ID
+			}
+			items = append(items, map[string]interface{}{op: res})
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"took":   0,
+		"errors": hasErrors,
+		"items":  items,
+	})
+}
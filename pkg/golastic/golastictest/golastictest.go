@@ -0,0 +1,465 @@
+// Package golastictest provides an in-memory fake of Elasticsearch for
+// testing code using golastic without a live cluster.
+//
+// The fake emulates the subset of Elasticsearch used by golastic: ping and
+// cluster health, index creation and existence, single document APIs, the
+// Bulk API, the Delete By Query API, and the
+// Search and Count APIs with match_all, multi_match, match, bool, term,
+// terms, range, ids, exists, nested and percolate queries, pagination,
+// sort, search_after, query rescorers and field collapsing.
+// Documents are searchable as soon as they are indexed. Points in time
+// are supported but are not snapshots: searches see the latest documents.
+//
+// Scripts are not executed, except the ones built by golastic such as
+// golastic.AppendScript and golastic.RemoveScript, which are emulated
+// in updates.
+//
+// Full text matching is approximated: texts are split into lowercase
+// terms, without analysis, and scored by the number of matching terms.
+// Unsupported requests fail with a 400 Bad Request.
+package golastictest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// Server is a fake Elasticsearch server storing the documents in memory.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	indices map[string]*index
+	lastID  int
+
+	// pits holds the index of each open point in time by ID.
+	pits    map[string]string
+	lastPIT int
+}
+
+// NewServer starts and returns a new Server. The caller should call
+// Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{indices: map[string]*index{}, pits: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns a new Elasticsearch client configured to send
+// its requests to the server.
+func (s *Server) Client() *elasticsearch.Client {
+	c, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{s.URL},
+	})
+	if err != nil {
+		// The configuration is static and cannot be invalid.
+		panic(fmt.Sprintf("golastictest: cannot create client: %s", err))
+	}
+	return c
+}
+
+// NewClient starts a new Server, shut down at the end of the test,
+// and returns a client configured to send its requests to it.
+func NewClient(t testing.TB) *elasticsearch.Client {
+	t.Helper()
+	s := NewServer()
+	t.Cleanup(s.Close)
+	return s.Client()
+}
+
+// Documents returns the sources of the documents of the given index
+// by ID, for assertions on the stored state.
+func (s *Server) Documents(name string) map[string]json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	docs := map[string]json.RawMessage{}
+	if idx, ok := s.indices[name]; ok {
+		for _, d := range idx.docs {
+			docs[d.id] = d.raw
+		}
+	}
+	return docs
+}
+
+// serveHTTP routes the requests to the emulated APIs.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/":
+		s.info(w)
+	case len(parts) == 2 && parts[0] == "_cluster" && parts[1] == "health":
+		s.health(w)
+	case len(parts) == 1 && parts[0] == "_bulk":
+		s.bulk(w, "", body)
+	case len(parts) == 1 && (parts[0] == "_search" || parts[0] == "_count"):
+		s.search(w, nil, parts[0], body)
+	case len(parts) == 1 && parts[0] == "_pit" && r.Method == http.MethodDelete:
+		s.closePointInTime(w, body)
+	case len(parts) == 1 && r.Method == http.MethodHead:
+		s.indexExists(w, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		s.createIndex(w, parts[0], body)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		s.deleteIndex(w, parts[0])
+	case len(parts) == 2 && parts[1] == "_bulk":
+		s.bulk(w, parts[0], body)
+	case len(parts) == 2 && parts[1] == "_pit" && r.Method == http.MethodPost:
+		s.openPointInTime(w, parts[0])
+	case len(parts) == 2 && (parts[1] == "_search" || parts[1] == "_count"):
+		s.search(w, strings.Split(parts[0], ","), parts[1], body)
+	case len(parts) == 2 && parts[1] == "_delete_by_query" && r.Method == http.MethodPost:
+		s.deleteByQuery(w, parts[0], body)
+	case len(parts) == 2 && parts[1] == "_doc" && r.Method == http.MethodPost:
+		s.indexDocument(w, parts[0], "", body)
+	case len(parts) == 3 && parts[1] == "_doc" && (r.Method == http.MethodPut || r.Method == http.MethodPost):
+		s.indexDocument(w, parts[0], parts[2], body)
+	case len(parts) == 3 && parts[1] == "_doc" && r.Method == http.MethodGet:
+		s.getDocument(w, parts[0], parts[2], r.URL.Query())
+	case len(parts) == 3 && parts[1] == "_doc" && r.Method == http.MethodDelete:
+		s.deleteDocument(w, parts[0], parts[2])
+	case len(parts) == 3 && parts[1] == "_update" && r.Method == http.MethodPost:
+		s.updateDocument(w, parts[0], parts[2], body)
+	case len(parts) == 4 && parts[1] == "_doc" && parts[3] == "_update" && r.Method == http.MethodPost:
+		// The 7.x client still uses the typed endpoint.
+		s.updateDocument(w, parts[0], parts[2], body)
+	default:
+		respondError(w, http.StatusBadRequest, "unsupported_operation_exception",
+			fmt.Sprintf("golastictest: unsupported request %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// -- Cluster
+
+func (s *Server) info(w http.ResponseWriter) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"name":         "golastictest",
+		"cluster_name": "golastictest",
+		"version":      map[string]interface{}{"number": "7.13.1"},
+		"tagline":      "You Know, for Search",
+	})
+}
+
+// health reports a green single node cluster with one primary shard
+// per index and no replicas.
+func (s *Server) health(w http.ResponseWriter) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"cluster_name":          "golastictest",
+		"status":                "green",
+		"number_of_nodes":       1,
+		"number_of_data_nodes":  1,
+		"active_primary_shards": len(s.indices),
+		"active_shards":         len(s.indices),
+		"relocating_shards":     0,
+		"initializing_shards":   0,
+		"unassigned_shards":     0,
+	})
+}
+
+// -- Indices
+
+func (s *Server) indexExists(w http.ResponseWriter, name string) {
+	if _, ok := s.indices[name]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) createIndex(w http.ResponseWriter, name string, body []byte) {
+	if _, ok := s.indices[name]; ok {
+		respondError(w, http.StatusBadRequest, "resource_already_exists_exception",
+			fmt.Sprintf("index [%s] already exists", name))
+		return
+	}
+	if len(body) != 0 && !json.Valid(body) {
+		respondError(w, http.StatusBadRequest, "parse_exception", "invalid index mapping")
+		return
+	}
+
+	s.indices[name] = &index{}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"acknowledged": true,
+		"index":        name,
+	})
+}
+
+func (s *Server) deleteIndex(w http.ResponseWriter, name string) {
+	if _, ok := s.indices[name]; !ok {
+		respondError(w, http.StatusNotFound, "index_not_found_exception", "no such index ["+name+"]")
+		return
+	}
+	delete(s.indices, name)
+	respondJSON(w, http.StatusOK, map[string]interface{}{"acknowledged": true})
+}
+
+// getIndex returns the index of the given name, created on the fly
+// as Elasticsearch does when indexing documents.
+func (s *Server) getIndex(name string) *index {
+	idx, ok := s.indices[name]
+	if !ok {
+		idx = &index{}
+		s.indices[name] = idx
+	}
+	return idx
+}
+
+// -- Points in time
+
+func (s *Server) openPointInTime(w http.ResponseWriter, name string) {
+	if _, ok := s.indices[name]; !ok {
+		respondError(w, http.StatusNotFound, "index_not_found_exception", "no such index ["+name+"]")
+		return
+	}
+	s.lastPIT++
+	id := fmt.Sprintf("pit%d", s.lastPIT)
+	s.pits[id] = name
+	respondJSON(w, http.StatusOK, map[string]interface{}{"id": id})
+}
+
+func (s *Server) closePointInTime(w http.ResponseWriter, body []byte) {
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+	if _, ok := s.pits[req.ID]; !ok {
+		respondJSON(w, http.StatusNotFound, map[string]interface{}{"succeeded": true, "num_freed": 0})
+		return
+	}
+	delete(s.pits, req.ID)
+	respondJSON(w, http.StatusOK, map[string]interface{}{"succeeded": true, "num_freed": 1})
+}
+
+// -- Documents
+
+func (s *Server) indexDocument(w http.ResponseWriter, name, id string, body []byte) {
+	status, res := s.put(name, id, body)
+	respondJSON(w, status, res)
+}
+
+// put stores the document in the given index and returns the status
+// and body of the response.
+func (s *Server) put(name, id string, body []byte) (int, map[string]interface{}) {
+	src, err := decodeSource(body)
+	if err != nil {
+		return errorBody(http.StatusBadRequest, "mapper_parsing_exception", err.Error())
+	}
+
+	if id == "" {
+		s.lastID++
+		id = fmt.Sprintf("doc%d", s.lastID)
+	}
+
+	result := "created"
+	status := http.StatusCreated
+	if s.getIndex(name).put(id, body, src) {
+		result = "updated"
+		status = http.StatusOK
+	}
+
+	return status, map[string]interface{}{
+		"_index": name,
+		"_id":    id,
+		"result": result,
+		"status": status,
+	}
+}
+
+func (s *Server) getDocument(w http.ResponseWriter, name, id string, params url.Values) {
+	idx, ok := s.indices[name]
+	if !ok {
+		respondError(w, http.StatusNotFound, "index_not_found_exception", "no such index ["+name+"]")
+		return
+	}
+
+	d := idx.get(id)
+	if d == nil {
+		respondJSON(w, http.StatusNotFound, map[string]interface{}{
+			"_index": name,
+			"_id":    id,
+			"found":  false,
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"_index":  name,
+		"_id":     id,
+		"found":   true,
+		"_source": filterSource(d.raw, d.src, splitParam(params, "_source_includes"), splitParam(params, "_source_excludes")),
+	})
+}
+
+func (s *Server) updateDocument(w http.ResponseWriter, name, id string, body []byte) {
+	status, res := s.update(name, id, body)
+	respondJSON(w, status, res)
+}
+
+// update merges the partial document of the body into the stored
+// document, or applies the script of the body to it, and returns
+// the status and body of the response.
+func (s *Server) update(name, id string, body []byte) (int, map[string]interface{}) {
+	var req struct {
+		Doc    map[string]interface{} `json:"doc"`
+		Script *golastic.Script       `json:"script"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return errorBody(http.StatusBadRequest, "x_content_parse_exception", err.Error())
+	}
+	if (req.Script == nil) == (req.Doc == nil) {
+		return errorBody(http.StatusBadRequest, "action_request_validation_exception",
+			"Validation Failed: 1: script or doc is missing;")
+	}
+
+	idx, ok := s.indices[name]
+	if !ok || idx.get(id) == nil {
+		return errorBody(http.StatusNotFound, "document_missing_exception",
+			fmt.Sprintf("[_doc][%s]: document missing", id))
+	}
+
+	d := idx.get(id)
+	if req.Script != nil {
+		if err := runScript(*req.Script, d.src); err != nil {
+			return errorBody(http.StatusBadRequest, "illegal_argument_exception", err.Error())
+		}
+	} else {
+		merge(d.src, req.Doc)
+	}
+	raw, err := json.Marshal(d.src)
+	if err != nil {
+		return errorBody(http.StatusInternalServerError, "exception", err.Error())
+	}
+	d.raw = raw
+
+	return http.StatusOK, map[string]interface{}{
+		"_index": name,
+		"_id":    id,
+		"result": "updated",
+		"status": http.StatusOK,
+	}
+}
+
+func (s *Server) deleteDocument(w http.ResponseWriter, name, id string) {
+	status, res := s.delete(name, id)
+	respondJSON(w, status, res)
+}
+
+// delete removes the document from the given index and returns
+// the status and body of the response.
+func (s *Server) delete(name, id string) (int, map[string]interface{}) {
+	idx, ok := s.indices[name]
+	if !ok || !idx.delete(id) {
+		return http.StatusNotFound, map[string]interface{}{
+			"_index": name,
+			"_id":    id,
+			"result": "not_found",
+			"status": http.StatusNotFound,
+		}
+	}
+	return http.StatusOK, map[string]interface{}{
+		"_index": name,
+		"_id":    id,
+		"result": "deleted",
+		"status": http.StatusOK,
+	}
+}
+
+// deleteByQuery removes the documents of the given index matching
+// the query of the request.
+func (s *Server) deleteByQuery(w http.ResponseWriter, name string, body []byte) {
+	var req struct {
+		Query json.RawMessage `json:"query"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+
+	idx, ok := s.indices[name]
+	if !ok {
+		respondError(w, http.StatusNotFound, "index_not_found_exception", "no such index ["+name+"]")
+		return
+	}
+
+	kept := idx.docs[:0]
+	deleted := 0
+	for _, d := range idx.docs {
+		ok, _, err := evaluate(req.Query, d)
+		if err != nil {
+			respondSearchError(w, err)
+			return
+		}
+		if ok {
+			deleted++
+			continue
+		}
+		kept = append(kept, d)
+	}
+	idx.docs = kept
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"total":    deleted,
+		"deleted":  deleted,
+		"failures": []interface{}{},
+	})
+}
+
+// splitParam returns the comma-separated values of a query parameter.
+func splitParam(params url.Values, key string) []string {
+	if v := params.Get(key); v != "" {
+		return strings.Split(v, ",")
+	}
+	return nil
+}
+
+// -- Responses
+
+// respondJSON writes the given status and body encoded as JSON.
+func respondJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) //nolint:errcheck // the client reads what it can
+}
+
+// respondError writes an error response shaped as Elasticsearch errors.
+func respondError(w http.ResponseWriter, status int, errType, reason string) {
+	status, body := errorBody(status, errType, reason)
+	respondJSON(w, status, body)
+}
+
+// errorBody returns the given status and an error body shaped
+// as Elasticsearch errors.
+func errorBody(status int, errType, reason string) (int, map[string]interface{}) {
+	return status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":   errType,
+			"reason": reason,
+		},
+		"status": status,
+	}
+}
@@ -0,0 +1,284 @@
+package golastictest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+	"github.com/moreirathomas/golastic/pkg/golastic/golastictest"
+)
+
+type book struct {
+	ID     string `json:"-"`
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+	Year   int    `json:"year,omitempty"`
+}
+
+func (b *book) SetMetadata(h golastic.Hit) {
+	b.ID = h.ID
+}
+
+// shelf is a document holding an array, updated by scripts.
+type shelf struct {
+	Books []string `json:"books"`
+}
+
+func newTestContext(t *testing.T) golastic.ContextConfig {
+	t.Helper()
+	client := golastictest.NewClient(t)
+	if _, err := golastic.Indices(client).CreateIfNotExists("books", `{}`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return golastic.ContextConfig{IndexName: "books", Client: client}
+}
+
+func TestDocuments(t *testing.T) {
+	ctx := newTestContext(t)
+	docs := golastic.DocumentOf[book](ctx)
+
+	id, err := docs.Index(book{Title: "Dune", Author: "Herbert", Year: 1965})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := docs.Update(id, book{Year: 1966}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b, err := docs.Get(id)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if exp := (book{ID: id, Title: "Dune", Author: "Herbert", Year: 1966}); b != exp {
+		t.Errorf("unexpected book: expected %v, got %v", exp, b)
+	}
+
+	if err := docs.Delete(id); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := docs.Get(id); !errors.Is(err, golastic.ErrNotFound) {
+		t.Errorf("unexpected error: expected %s, got %v", golastic.ErrNotFound, err)
+	}
+	if err := docs.Update(id, book{Year: 1966}); !errors.Is(err, golastic.ErrNotFound) {
+		t.Errorf("unexpected error: expected %s, got %v", golastic.ErrNotFound, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ctx := newTestContext(t)
+	err := golastic.DocumentOf[book](ctx).Bulk([]book{
+		{Title: "Dune", Author: "Herbert", Year: 1965},
+		{Title: "Children of Dune", Author: "Herbert", Year: 1976},
+		{Title: "Foundation", Author: "Asimov", Year: 1951},
+		{Title: "Hyperion", Author: "Simmons", Year: 1989},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	testCases := []struct {
+		name  string
+		query golastic.SearchQuery
+		page  golastic.SearchPagination
+		sort  golastic.SearchSort
+		exp   []string
+		total int
+	}{
+		{
+			name:  "match all in index order",
+			query: golastic.NewMatchAllQuery(),
+			page:  golastic.SearchPagination{Size: 10},
+			exp:   []string{"Dune", "Children of Dune", "Foundation", "Hyperion"},
+			total: 4,
+		},
+		{
+			name: "multi match by score",
+			query: golastic.NewMultiMatchQuery("children dune", []golastic.Field{
+				{Name: "title"}, {Name: "author"},
+			}),
+			page:  golastic.SearchPagination{Size: 10},
+			sort:  golastic.SearchSort{golastic.SortByScore()},
+			exp:   []string{"Children of Dune"},
+			total: 1,
+		},
+		{
+			name:  "bool with exists and term, sorted and paginated",
+			query: boolQuery(),
+			page:  golastic.SearchPagination{From: 1, Size: 1},
+			sort:  golastic.SearchSort{golastic.SortBy("year").Desc()},
+			exp:   []string{"Dune"},
+			total: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := golastic.SearchOf[book](ctx).Query(tc.query, tc.page, tc.sort)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			titles := []string{}
+			for _, b := range res.Documents {
+				titles = append(titles, b.Title)
+			}
+			if len(titles) != len(tc.exp) {
+				t.Fatalf("unexpected titles: expected %v, got %v", tc.exp, titles)
+			}
+			for i := range titles {
+				if titles[i] != tc.exp[i] {
+					t.Errorf("unexpected titles: expected %v, got %v", tc.exp, titles)
+				}
+			}
+			if res.TotalHits() != tc.total {
+				t.Errorf("unexpected total hits: expected %d, got %d", tc.total, res.TotalHits())
+			}
+		})
+	}
+}
+
+// boolQuery returns a query matching the books with a year,
+// except the ones by Simmons.
+func boolQuery() golastic.SearchQuery {
+	q := golastic.SearchQuery{}
+	q.Query.Bool = &golastic.BoolQuery{
+		Filter: []golastic.Query{{Exists: &golastic.ExistsQuery{Field: "year"}}},
+		MustNot: []golastic.Query{
+			{Term: &golastic.TermQuery{Field: "author.keyword", Value: "Simmons"}},
+		},
+	}
+	return q
+}
+
+func TestDeleteByQuery(t *testing.T) {
+	ctx := newTestContext(t)
+	docs := golastic.DocumentOf[book](ctx)
+	err := docs.Bulk([]book{
+		{Title: "Dune", Author: "Herbert"},
+		{Title: "Children of Dune", Author: "Herbert"},
+		{Title: "Hyperion", Author: "Simmons"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	q := golastic.SearchQuery{}
+	q.Query.Term = &golastic.TermQuery{Field: "author.keyword", Value: "Herbert"}
+	n, err := docs.DeleteByQuery(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n != 2 {
+		t.Errorf("unexpected deleted count: expected 2, got %d", n)
+	}
+
+	res, err := golastic.SearchOf[book](ctx).Query(golastic.NewMatchAllQuery(), golastic.SearchPagination{Size: 10}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(res.Documents) != 1 || res.Documents[0].Title != "Hyperion" {
+		t.Errorf("unexpected books: %v", res.Documents)
+	}
+}
+
+func TestUnsupportedQuery(t *testing.T) {
+	ctx := newTestContext(t)
+
+	q := golastic.SearchQuery{}
+	q.Query.GeoDistance = golastic.NewGeoDistanceQuery("location", golastic.GeoPoint{}, "10km").Query.GeoDistance
+	_, err := golastic.Search(ctx).Query(q, golastic.SearchPagination{Size: 10}, nil)
+	if !errors.Is(err, golastic.ErrBadRequest) {
+		t.Errorf("unexpected error: expected %s, got %v", golastic.ErrBadRequest, err)
+	}
+}
+
+func TestRescoreAndCollapse(t *testing.T) {
+	ctx := newTestContext(t)
+	err := golastic.DocumentOf[book](ctx).Bulk([]book{
+		{Title: "Dune", Author: "Herbert", Year: 1965},
+		{Title: "Children of Dune", Author: "Herbert", Year: 1976},
+		{Title: "Foundation", Author: "Asimov", Year: 1951},
+		{Title: "Hyperion", Author: "Simmons", Year: 1989},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	page := golastic.SearchPagination{Size: 10}
+	titles := func(res *golastic.TypedSearchResult[book]) []string {
+		titles := []string{}
+		for _, b := range res.Documents {
+			titles = append(titles, b.Title)
+		}
+		return titles
+	}
+
+	res, err := golastic.SearchOf[book](ctx).
+		WithRescore(golastic.Rescore{Query: golastic.RescoreQuery{
+			RescoreQuery:       golastic.Query{Term: &golastic.TermQuery{Field: "author.keyword", Value: "Asimov"}},
+			RescoreQueryWeight: 2,
+		}}).
+		Query(golastic.NewMatchAllQuery(), page, golastic.SearchSort{golastic.SortByScore()})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, exp := titles(res), []string{"Foundation", "Dune", "Children of Dune", "Hyperion"}; fmt.Sprint(got) != fmt.Sprint(exp) {
+		t.Errorf("unexpected rescored titles: expected %v, got %v", exp, got)
+	}
+
+	res, err = golastic.SearchOf[book](ctx).
+		WithCollapse(golastic.Collapse{Field: "author.keyword"}).
+		Query(golastic.NewMatchAllQuery(), page, golastic.SearchSort{golastic.SortBy("year").Desc()})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, exp := titles(res), []string{"Hyperion", "Children of Dune", "Foundation"}; fmt.Sprint(got) != fmt.Sprint(exp) {
+		t.Errorf("unexpected collapsed titles: expected %v, got %v", exp, got)
+	}
+	if res.TotalHits() != 4 {
+		t.Errorf("unexpected total hits: expected 4, got %d", res.TotalHits())
+	}
+
+	// Elasticsearch rejects rescoring hits sorted by a field.
+	_, err = golastic.SearchOf[book](ctx).
+		WithRescore(golastic.Rescore{Query: golastic.RescoreQuery{RescoreQuery: golastic.NewMatchAllQuery().Query}}).
+		Query(golastic.NewMatchAllQuery(), page, golastic.SearchSort{golastic.SortBy("year")})
+	if !errors.Is(err, golastic.ErrBadRequest) {
+		t.Errorf("unexpected error: expected %s, got %v", golastic.ErrBadRequest, err)
+	}
+}
+
+func TestEachPageAndBulkUpdateByScript(t *testing.T) {
+	ctx := newTestContext(t)
+	docs := []shelf{{Books: []string{}}, {Books: []string{"dune"}}, {Books: []string{}}, {Books: []string{}}, {Books: []string{}}}
+	if err := golastic.DocumentOf[shelf](ctx).Bulk(docs); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pages := 0
+	err := golastic.Search(ctx).EachPage(golastic.NewMatchAllQuery(), 2, func(res *golastic.SearchResult) error {
+		pages++
+		ids := []string{}
+		for _, h := range res.Hits.Hits {
+			ids = append(ids, h.ID)
+		}
+		return golastic.Document(ctx).BulkUpdateByScript(ids, golastic.AppendScript("books", "dune"))
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if pages != 3 {
+		t.Errorf("unexpected number of pages: expected 3, got %d", pages)
+	}
+
+	res, err := golastic.SearchOf[shelf](ctx).Query(golastic.NewMatchAllQuery(), golastic.SearchPagination{Size: 10}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, s := range res.Documents {
+		if fmt.Sprint(s.Books) != "[dune]" {
+			t.Errorf("unexpected books: expected [dune], got %v", s.Books)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package golastictest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// index holds the documents of an index in insertion order.
+type index struct {
+	docs []*document
+}
+
+// document is a stored document. Its source is kept both raw, as returned
+// by the APIs, and decoded, to evaluate the queries.
+type document struct {
+	id  string
+	raw json.RawMessage
+	src map[string]interface{}
+}
+
+// get returns the document of the given ID, or nil if it does not exist.
+func (idx *index) get(id string) *document {
+	for _, d := range idx.docs {
+		if d.id == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// put stores the document of the given ID and reports whether
+// it replaced an existing document.
+func (idx *index) put(id string, raw []byte, src map[string]interface{}) bool {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err == nil {
+		raw = buf.Bytes()
+	}
+
+	if d := idx.get(id); d != nil {
+		d.raw, d.src = raw, src
+		return true
+	}
+	idx.docs = append(idx.docs, &document{id: id, raw: raw, src: src})
+	return false
+}
+
+// delete removes the document of the given ID and reports whether it existed.
+func (idx *index) delete(id string) bool {
+	for i, d := range idx.docs {
+		if d.id == id {
+			idx.docs = append(idx.docs[:i], idx.docs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// decodeSource decodes a document source, which must be a JSON object.
+func decodeSource(b []byte) (map[string]interface{}, error) {
+	var src map[string]interface{}
+	if err := json.Unmarshal(b, &src); err != nil {
+		return nil, err
+	}
+	if src == nil {
+		return nil, errors.New("document source must be an object")
+	}
+	return src, nil
+}
+
+// merge merges the fields of the partial document into dst,
+// recursively for objects.
+func merge(dst, partial map[string]interface{}) {
+	for k, v := range partial {
+		sub, ok := v.(map[string]interface{})
+		cur, isObj := dst[k].(map[string]interface{})
+		if ok && isObj {
+			merge(cur, sub)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// values returns the values of the field at the given dotted path,
+// flattening arrays. As the fake has no mapping, a keyword subfield
+// such as "title.keyword" resolves to the values of its parent field.
+func values(src map[string]interface{}, path string) []interface{} {
+	vs := lookup(src, strings.Split(path, "."))
+	if len(vs) == 0 && strings.HasSuffix(path, ".keyword") {
+		return values(src, strings.TrimSuffix(path, ".keyword"))
+	}
+	return vs
+}
+
+// lookup returns the values found under the given keys of v.
+func lookup(v interface{}, keys []string) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		var vs []interface{}
+		for _, e := range v {
+			vs = append(vs, lookup(e, keys)...)
+		}
+		return vs
+	case map[string]interface{}:
+		if len(keys) == 0 {
+			return []interface{}{v}
+		}
+		return lookup(v[keys[0]], keys[1:])
+	case nil:
+		return nil
+	default:
+		if len(keys) != 0 {
+			return nil
+		}
+		return []interface{}{v}
+	}
+}
+
+// filterSource returns the source restricted to the top-level fields
+// matching includes and not matching excludes.
+func filterSource(raw json.RawMessage, src map[string]interface{}, includes, excludes []string) json.RawMessage {
+	if len(includes) == 0 && len(excludes) == 0 {
+		return raw
+	}
+
+	filtered := map[string]interface{}{}
+	for k, v := range src {
+		if (len(includes) == 0 || matchAny(includes, k)) && !matchAny(excludes, k) {
+			filtered[k] = v
+		}
+	}
+	b, _ := json.Marshal(filtered)
+	return b
+}
+
+// matchAny reports whether the field matches any of the patterns,
+// which support a trailing wildcard.
+func matchAny(patterns []string, field string) bool {
+	for _, p := range patterns {
+		if p == field || strings.HasPrefix(p, field+".") {
+			return true
+		}
+		if strings.HasSuffix(p, "*") && strings.HasPrefix(field, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,611 @@
+package golastictest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// unsupportedError is returned for the parts of a request
+// the fake does not emulate.
+type unsupportedError struct {
+	what string
+}
+
+func (e unsupportedError) Error() string {
+	return fmt.Sprintf("golastictest: unsupported %s", e.what)
+}
+
+// evaluate reports whether the document matches the query and its score.
+func evaluate(q json.RawMessage, d *document) (bool, float64, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(q, &m); err != nil {
+		return false, 0, err
+	}
+	if len(m) != 1 {
+		return false, 0, fmt.Errorf("query malformed, expected a single query type, got %d", len(m))
+	}
+
+	for typ, body := range m {
+		switch typ {
+		case "match_all":
+			return true, 1, nil
+		case "match_none":
+			return false, 0, nil
+		case "multi_match":
+			return evaluateMultiMatch(body, d)
+		case "match":
+			return evaluateMatch(body, d, "")
+		case "match_phrase":
+			return evaluateMatch(body, d, "phrase")
+		case "bool":
+			return evaluateBool(body, d)
+		case "term":
+			return evaluateTerm(body, d)
+		case "terms":
+			return evaluateTerms(body, d)
+		case "range":
+			return evaluateRange(body, d)
+		case "ids":
+			return evaluateIDs(body, d)
+		case "exists":
+			return evaluateExists(body, d)
+		case "nested":
+			return evaluateNested(body, d)
+		case "percolate":
+			return evaluatePercolate(body, d)
+		default:
+			return false, 0, unsupportedError{fmt.Sprintf("query [%s]", typ)}
+		}
+	}
+	return false, 0, nil
+}
+
+// -- Full text queries
+
+// textField is a field of a full text query along with its boost.
+type textField struct {
+	name   string
+	weight float64
+}
+
+// parseTextField parses a field such as "title^10".
+func parseTextField(s string) (textField, error) {
+	name, weight := s, 1.0
+	if i := strings.LastIndex(s, "^"); i != -1 {
+		w, err := strconv.ParseFloat(s[i+1:], 64)
+		if err != nil {
+			return textField{}, fmt.Errorf("invalid field boost %q", s)
+		}
+		name, weight = s[:i], w
+	}
+	return textField{name: name, weight: weight}, nil
+}
+
+// terms splits the text into lowercase terms on non-alphanumeric runes.
+func terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// fieldTerms returns the terms of all the values of the field.
+func fieldTerms(d *document, field string) [][]string {
+	var ts [][]string
+	for _, v := range values(d.src, field) {
+		switch v.(type) {
+		case map[string]interface{}, bool:
+			continue
+		}
+		ts = append(ts, terms(fmt.Sprint(v)))
+	}
+	return ts
+}
+
+// countTerms returns how many of the query terms are found in the field.
+// For phrases, it returns either zero or the number of query terms if they
+// are found consecutively.
+func countTerms(d *document, field string, qs []string, phrase bool) int {
+	best := 0
+	for _, ts := range fieldTerms(d, field) {
+		n := 0
+		if phrase {
+			if containsPhrase(ts, qs) {
+				n = len(qs)
+			}
+		} else {
+			for _, q := range qs {
+				if contains(ts, q) {
+					n++
+				}
+			}
+		}
+		if n > best {
+			best = n
+		}
+	}
+	return best
+}
+
+func contains(ts []string, t string) bool {
+	for _, s := range ts {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPhrase(ts, phrase []string) bool {
+	if len(phrase) == 0 {
+		return false
+	}
+	for i := 0; i+len(phrase) <= len(ts); i++ {
+		if strings.Join(ts[i:i+len(phrase)], " ") == strings.Join(phrase, " ") {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateMultiMatch evaluates a multi_match query. The score of a field
+// is its boost times the number of matching terms. The best_fields type
+// keeps the best field score, most_fields sums them and cross_fields
+// matches the terms as if the fields were a single field.
+func evaluateMultiMatch(body json.RawMessage, d *document) (bool, float64, error) {
+	var q struct {
+		Query    string   `json:"query"`
+		Fields   []string `json:"fields"`
+		Type     string   `json:"type"`
+		Operator string   `json:"operator"`
+	}
+	if err := json.Unmarshal(body, &q); err != nil {
+		return false, 0, err
+	}
+
+	fields := make([]textField, 0, len(q.Fields))
+	for _, f := range q.Fields {
+		tf, err := parseTextField(f)
+		if err != nil {
+			return false, 0, err
+		}
+		fields = append(fields, tf)
+	}
+	if len(fields) == 0 {
+		for k := range d.src {
+			fields = append(fields, textField{name: k, weight: 1})
+		}
+	}
+
+	return matchText(d, q.Query, fields, q.Type, q.Operator)
+}
+
+// evaluateMatch evaluates a match query, or a match_phrase query
+// if typ is "phrase".
+func evaluateMatch(body json.RawMessage, d *document, typ string) (bool, float64, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return false, 0, err
+	}
+
+	for field, v := range m {
+		var q struct {
+			Query    string `json:"query"`
+			Operator string `json:"operator"`
+		}
+		if err := json.Unmarshal(v, &q.Query); err != nil {
+			if err := json.Unmarshal(v, &q); err != nil {
+				return false, 0, err
+			}
+		}
+		return matchText(d, q.Query, []textField{{name: field, weight: 1}}, typ, q.Operator)
+	}
+	return false, 0, nil
+}
+
+// matchText matches the query string against the given fields.
+func matchText(d *document, query string, fields []textField, typ, operator string) (bool, float64, error) {
+	qs := terms(query)
+	if len(qs) == 0 {
+		return false, 0, nil
+	}
+	all := strings.EqualFold(operator, "and")
+
+	switch typ {
+	case "", "best_fields", "most_fields", "phrase":
+		matched, score := false, 0.0
+		for _, f := range fields {
+			n := countTerms(d, f.name, qs, typ == "phrase")
+			if n == 0 || (all && n < len(qs)) {
+				continue
+			}
+			matched = true
+			s := f.weight * float64(n)
+			if typ == "most_fields" {
+				score += s
+			} else if s > score {
+				score = s
+			}
+		}
+		return matched, score, nil
+
+	case "cross_fields":
+		found, score := 0, 0.0
+		for _, q := range qs {
+			best := 0.0
+			for _, f := range fields {
+				if countTerms(d, f.name, []string{q}, false) != 0 && f.weight > best {
+					best = f.weight
+				}
+			}
+			if best != 0 {
+				found++
+				score += best
+			}
+		}
+		if found == 0 || (all && found < len(qs)) {
+			return false, 0, nil
+		}
+		return true, score, nil
+
+	default:
+		return false, 0, unsupportedError{fmt.Sprintf("multi_match type [%s]", typ)}
+	}
+}
+
+// -- Compound queries
+
+// evaluateBool evaluates a bool query. Its score is the sum of the scores
+// of its matching must and should clauses.
+func evaluateBool(body json.RawMessage, d *document) (bool, float64, error) {
+	var q struct {
+		Must               json.RawMessage `json:"must"`
+		Filter             json.RawMessage `json:"filter"`
+		Should             json.RawMessage `json:"should"`
+		MustNot            json.RawMessage `json:"must_not"`
+		MinimumShouldMatch json.RawMessage `json:"minimum_should_match"`
+	}
+	if err := json.Unmarshal(body, &q); err != nil {
+		return false, 0, err
+	}
+
+	must, err := clauses(q.Must)
+	if err != nil {
+		return false, 0, err
+	}
+	filter, err := clauses(q.Filter)
+	if err != nil {
+		return false, 0, err
+	}
+	should, err := clauses(q.Should)
+	if err != nil {
+		return false, 0, err
+	}
+	mustNot, err := clauses(q.MustNot)
+	if err != nil {
+		return false, 0, err
+	}
+
+	score := 0.0
+	for _, c := range must {
+		ok, s, err := evaluate(c, d)
+		if err != nil || !ok {
+			return false, 0, err
+		}
+		score += s
+	}
+	for _, c := range filter {
+		ok, _, err := evaluate(c, d)
+		if err != nil || !ok {
+			return false, 0, err
+		}
+	}
+	for _, c := range mustNot {
+		ok, _, err := evaluate(c, d)
+		if err != nil || ok {
+			return false, 0, err
+		}
+	}
+
+	minShould := 0
+	if len(must) == 0 && len(filter) == 0 && len(should) != 0 {
+		minShould = 1
+	}
+	if len(q.MinimumShouldMatch) != 0 {
+		n, err := strconv.Atoi(strings.Trim(string(q.MinimumShouldMatch), `"`))
+		if err != nil {
+			return false, 0, unsupportedError{fmt.Sprintf("minimum_should_match [%s]", q.MinimumShouldMatch)}
+		}
+		minShould = n
+	}
+
+	matched := 0
+	for _, c := range should {
+		ok, s, err := evaluate(c, d)
+		if err != nil {
+			return false, 0, err
+		}
+		if ok {
+			matched++
+			score += s
+		}
+	}
+	if matched < minShould {
+		return false, 0, nil
+	}
+
+	return true, score, nil
+}
+
+// clauses returns the queries of a bool clause, which is either
+// a single query or an array of queries.
+func clauses(raw json.RawMessage) ([]json.RawMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	if raw[0] != '[' {
+		return []json.RawMessage{raw}, nil
+	}
+	var cs []json.RawMessage
+	err := json.Unmarshal(raw, &cs)
+	return cs, err
+}
+
+// evaluateNested evaluates the query against each nested object at the
+// path. The document matches if any object matches, with the average
+// score of the matching objects.
+func evaluateNested(body json.RawMessage, d *document) (bool, float64, error) {
+	var q struct {
+		Path  string          `json:"path"`
+		Query json.RawMessage `json:"query"`
+	}
+	if err := json.Unmarshal(body, &q); err != nil {
+		return false, 0, err
+	}
+
+	matched, score := 0, 0.0
+	for _, v := range values(d.src, q.Path) {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		view := &document{id: d.id, src: withPath(d.src, strings.Split(q.Path, "."), obj)}
+		ok, s, err := evaluate(q.Query, view)
+		if err != nil {
+			return false, 0, err
+		}
+		if ok {
+			matched++
+			score += s
+		}
+	}
+	if matched == 0 {
+		return false, 0, nil
+	}
+	return true, score / float64(matched), nil
+}
+
+// withPath returns a shallow copy of src with the value at path replaced
+// by v, so that the fields of a single nested object are evaluated together.
+func withPath(src map[string]interface{}, path []string, v interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(src))
+	for k, e := range src {
+		cp[k] = e
+	}
+	if len(path) == 1 {
+		cp[path[0]] = v
+		return cp
+	}
+	sub, _ := src[path[0]].(map[string]interface{})
+	cp[path[0]] = withPath(sub, path[1:], v)
+	return cp
+}
+
+// -- Term level queries
+
+// fieldQuery returns the single field of a term level query
+// and its raw parameters.
+func fieldQuery(body json.RawMessage) (string, json.RawMessage, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return "", nil, err
+	}
+	delete(m, "boost")
+	if len(m) != 1 {
+		return "", nil, fmt.Errorf("query malformed, expected a single field, got %d", len(m))
+	}
+	for f, v := range m {
+		return f, v, nil
+	}
+	return "", nil, nil
+}
+
+// evaluateTerm evaluates a term query. The values are compared exactly.
+func evaluateTerm(body json.RawMessage, d *document) (bool, float64, error) {
+	field, raw, err := fieldQuery(body)
+	if err != nil {
+		return false, 0, err
+	}
+
+	var value interface{}
+	var q struct {
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return false, 0, err
+	}
+	if _, ok := value.(map[string]interface{}); ok {
+		if err := json.Unmarshal(raw, &q); err != nil {
+			return false, 0, err
+		}
+		value = q.Value
+	}
+
+	return matchAnyValue(d, field, []interface{}{value}), 1, nil
+}
+
+// evaluateTerms evaluates a terms query.
+func evaluateTerms(body json.RawMessage, d *document) (bool, float64, error) {
+	field, raw, err := fieldQuery(body)
+	if err != nil {
+		return false, 0, err
+	}
+
+	var vs []interface{}
+	if err := json.Unmarshal(raw, &vs); err != nil {
+		return false, 0, unsupportedError{"terms lookup"}
+	}
+	return matchAnyValue(d, field, vs), 1, nil
+}
+
+// matchAnyValue reports whether any value of the field equals any of vs.
+// A join field matches on its relation name.
+func matchAnyValue(d *document, field string, vs []interface{}) bool {
+	for _, v := range values(d.src, field) {
+		if join, ok := v.(map[string]interface{}); ok {
+			v = join["name"]
+		}
+		for _, want := range vs {
+			if c, ok := compare(v, want); ok && c == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluateRange evaluates a range query on numbers or strings, such as
+// dates in the same format. Date math is not supported.
+func evaluateRange(body json.RawMessage, d *document) (bool, float64, error) {
+	field, raw, err := fieldQuery(body)
+	if err != nil {
+		return false, 0, err
+	}
+
+	var bounds map[string]interface{}
+	if err := json.Unmarshal(raw, &bounds); err != nil {
+		return false, 0, err
+	}
+	for op, b := range bounds {
+		switch op {
+		case "gt", "gte", "lt", "lte":
+		default:
+			return false, 0, unsupportedError{fmt.Sprintf("range parameter [%s]", op)}
+		}
+		if s, ok := b.(string); ok && strings.HasPrefix(s, "now") {
+			return false, 0, unsupportedError{"date math"}
+		}
+	}
+
+next:
+	for _, v := range values(d.src, field) {
+		for op, b := range bounds {
+			c, ok := compare(v, b)
+			if !ok {
+				continue next
+			}
+			if (op == "gt" && c <= 0) || (op == "gte" && c < 0) ||
+				(op == "lt" && c >= 0) || (op == "lte" && c > 0) {
+				continue next
+			}
+		}
+		return true, 1, nil
+	}
+	return false, 0, nil
+}
+
+// evaluateIDs evaluates an ids query.
+func evaluateIDs(body json.RawMessage, d *document) (bool, float64, error) {
+	var q struct {
+		Values []string `json:"values"`
+	}
+	if err := json.Unmarshal(body, &q); err != nil {
+		return false, 0, err
+	}
+	for _, id := range q.Values {
+		if id == d.id {
+			return true, 1, nil
+		}
+	}
+	return false, 0, nil
+}
+
+// evaluateExists evaluates an exists query.
+func evaluateExists(body json.RawMessage, d *document) (bool, float64, error) {
+	var q struct {
+		Field string `json:"field"`
+	}
+	if err := json.Unmarshal(body, &q); err != nil {
+		return false, 0, err
+	}
+	return len(values(d.src, q.Field)) != 0, 1, nil
+}
+
+// compare compares two JSON values of the same kind. It reports false
+// if they cannot be compared.
+func compare(a, b interface{}) (int, bool) {
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a < b:
+			return -1, true
+		case a > b:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(a, b), true
+	case bool:
+		b, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a == b:
+			return 0, true
+		case !a:
+			return -1, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+// -- Specialized queries
+
+// evaluatePercolate evaluates a percolate query: the query stored in the
+// percolator field of the document is evaluated against the given document.
+func evaluatePercolate(body json.RawMessage, d *document) (bool, float64, error) {
+	var q struct {
+		Field    string                 `json:"field"`
+		Document map[string]interface{} `json:"document"`
+	}
+	if err := json.Unmarshal(body, &q); err != nil {
+		return false, 0, err
+	}
+	if q.Document == nil {
+		return false, 0, unsupportedError{"percolate query without inline [document]"}
+	}
+
+	stored, ok := d.src[q.Field]
+	if !ok {
+		return false, 0, nil
+	}
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		return false, 0, err
+	}
+	return evaluate(raw, &document{src: q.Document})
+}
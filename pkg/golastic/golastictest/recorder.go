@@ -0,0 +1,325 @@
+package golastictest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+// Recorder modes.
+const (
+	// ModeReplay replays the recorded interactions without sending
+	// any request. Unrecorded requests fail.
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests and records the interactions,
+	// overwriting the fixture file.
+	ModeRecord
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+// RecordEnv is the environment variable enabling ModeRecord in
+// NewRecordingClient. The requests are then sent to the address of
+// ELASTICSEARCH_URL, or to http://localhost:9200 if not set.
+const RecordEnv = "GOLASTIC_RECORD"
+
+// Recorder is an http.RoundTripper recording request/response pairs to
+// a fixture file and replaying them, for deterministic tests running
+// against captured Elasticsearch responses.
+//
+// Requests are matched on their method, path, query and body. JSON bodies,
+// including NDJSON bodies of bulk requests, match regardless of the order
+// of their object keys.
+type Recorder struct {
+	path string
+	mode Mode
+
+	// Transport sends the requests in ModeRecord.
+	// http.DefaultTransport is used if nil.
+	Transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []*interaction
+	loaded       bool
+}
+
+// interaction is a recorded request/response pair.
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+
+	replayed bool
+}
+
+type recordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Body   fixtureBody `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	StatusCode int               `json:"status_code"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       fixtureBody       `json:"body,omitempty"`
+}
+
+// NewRecorder returns a Recorder of the given mode using the fixture
+// file at path.
+func NewRecorder(path string, mode Mode) *Recorder {
+	return &Recorder{path: path, mode: mode}
+}
+
+// NewRecordingClient returns a client whose requests are replayed from the
+// fixture file at path, or recorded to it if RecordEnv is set. For instance:
+//
+//	GOLASTIC_RECORD=1 go test ./internal/repository
+func NewRecordingClient(t testing.TB, path string) *elasticsearch.Client {
+	t.Helper()
+
+	mode := ModeReplay
+	if os.Getenv(RecordEnv) != "" {
+		mode = ModeRecord
+	}
+
+	c, err := elasticsearch.NewClient(elasticsearch.Config{
+		Transport: NewRecorder(path, mode),
+	})
+	if err != nil {
+		t.Fatalf("golastictest: cannot create client: %s", err)
+	}
+	return c
+}
+
+// RoundTrip replays or records the given request depending on the mode.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	rr := recordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		Body:   fixtureBody(body),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == ModeRecord {
+		return r.record(req, rr)
+	}
+	return r.replay(req, rr)
+}
+
+// record sends the request and saves the interaction.
+func (r *Recorder) record(req *http.Request, rr recordedRequest) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := map[string]string{}
+	for k := range res.Header {
+		if k == "Content-Type" || k == "X-Elastic-Product" {
+			header[k] = res.Header.Get(k)
+		}
+	}
+
+	r.interactions = append(r.interactions, &interaction{
+		Request: rr,
+		Response: recordedResponse{
+			StatusCode: res.StatusCode,
+			Header:     header,
+			Body:       fixtureBody(body),
+		},
+	})
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+// replay returns the response of the first recorded interaction matching
+// the request that was not replayed yet. Once all the matching interactions
+// are replayed, the last one is replayed again.
+func (r *Recorder) replay(req *http.Request, rr recordedRequest) (*http.Response, error) {
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	var match *interaction
+	for _, in := range r.interactions {
+		if !in.Request.matches(rr) {
+			continue
+		}
+		match = in
+		if !in.replayed {
+			break
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("golastictest: no interaction recorded in %s for %s %s %s",
+			r.path, rr.Method, rr.Path, rr.Body)
+	}
+	match.replayed = true
+
+	header := http.Header{}
+	for k, v := range match.Response.Header {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		StatusCode:    match.Response.StatusCode,
+		Status:        fmt.Sprintf("%d %s", match.Response.StatusCode, http.StatusText(match.Response.StatusCode)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(match.Response.Body)),
+		ContentLength: int64(len(match.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// load reads the fixture file once.
+func (r *Recorder) load() error {
+	if r.loaded {
+		return nil
+	}
+
+	b, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("golastictest: cannot read fixture: %w", err)
+	}
+	if err := json.Unmarshal(b, &r.interactions); err != nil {
+		return fmt.Errorf("golastictest: cannot decode fixture %s: %w", r.path, err)
+	}
+	r.loaded = true
+	return nil
+}
+
+// save writes the recorded interactions to the fixture file.
+func (r *Recorder) save() error {
+	b, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(b, '\n'), 0o644) //nolint:gosec // fixtures are not secret
+}
+
+// readRequestBody reads the body of the request and restores it
+// so that the request can still be sent.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// matches reports whether the recorded request matches the given request.
+func (rr recordedRequest) matches(other recordedRequest) bool {
+	return rr.Method == other.Method &&
+		rr.Path == other.Path &&
+		rr.Query == other.Query &&
+		bytes.Equal(canonicalBody(rr.Body), canonicalBody(other.Body))
+}
+
+// canonicalBody returns the body with its JSON values, or NDJSON lines,
+// re-encoded with sorted object keys. Other bodies are returned as is.
+func canonicalBody(b []byte) []byte {
+	if c, ok := canonicalJSON(b); ok {
+		return c
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	canonical := make([][]byte, 0, len(lines))
+	for _, l := range lines {
+		c, ok := canonicalJSON(l)
+		if !ok {
+			return b
+		}
+		canonical = append(canonical, c)
+	}
+	return bytes.Join(canonical, []byte("\n"))
+}
+
+// canonicalJSON returns the JSON value re-encoded with sorted object keys,
+// or false if b is not a single JSON value.
+func canonicalJSON(b []byte) ([]byte, bool) {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, false
+	}
+	// Maps are encoded with sorted keys.
+	c, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	return c, true
+}
+
+// fixtureBody is a body stored in a fixture file. JSON bodies are stored
+// as JSON for readability, other bodies as strings.
+type fixtureBody []byte
+
+// MarshalJSON returns the body as is if it is valid JSON,
+// as a JSON string otherwise.
+func (b fixtureBody) MarshalJSON() ([]byte, error) {
+	if len(b) == 0 {
+		return []byte(`""`), nil
+	}
+	if json.Valid(b) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, b); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return json.Marshal(string(b))
+}
+
+// UnmarshalJSON decodes a body stored either as JSON or as a string.
+func (b *fixtureBody) UnmarshalJSON(p []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(p), []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(p, &s); err != nil {
+			return err
+		}
+		*b = fixtureBody(s)
+		return nil
+	}
+	*b = append((*b)[:0], p...)
+	return nil
+}
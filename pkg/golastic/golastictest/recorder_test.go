@@ -0,0 +1,75 @@
+package golastictest_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+	"github.com/moreirathomas/golastic/pkg/golastic/golastictest"
+)
+
+func TestRecorder(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "search.json")
+
+	// Record the interactions with a fake server, closed before replaying.
+	s := golastictest.NewServer()
+	recording := newRecorderClient(t, s.URL, golastictest.NewRecorder(fixture, golastictest.ModeRecord))
+	ctx := golastic.ContextConfig{IndexName: "books", Client: recording}
+	if _, err := golastic.DocumentOf[book](ctx).Index(book{Title: "Dune"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	search(t, recording, `{"query":{"match":{"title":"dune"}},"size":10}`)
+	s.Close()
+
+	replaying := newRecorderClient(t, s.URL, golastictest.NewRecorder(fixture, golastictest.ModeReplay))
+
+	// Key order does not matter.
+	if n := search(t, replaying, `{"size":10,"query":{"match":{"title":"dune"}}}`); n != 1 {
+		t.Errorf("unexpected replayed hits: expected 1, got %d", n)
+	}
+
+	res, err := replaying.Search(
+		replaying.Search.WithIndex("books"),
+		replaying.Search.WithBody(strings.NewReader(`{"query":{"match_all":{}}}`)),
+	)
+	if err == nil {
+		res.Body.Close()
+		t.Errorf("unexpected success replaying an unrecorded request")
+	}
+}
+
+func newRecorderClient(t *testing.T, addr string, r *golastictest.Recorder) *elasticsearch.Client {
+	t.Helper()
+	c, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{addr},
+		Transport: r,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return c
+}
+
+// search performs the search of the given body on the books index
+// and returns the number of hits.
+func search(t *testing.T, c *elasticsearch.Client, body string) int {
+	t.Helper()
+	res, err := c.Search(
+		c.Search.WithIndex("books"),
+		c.Search.WithBody(strings.NewReader(body)),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+
+	var r golastic.SearchResult
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return r.TotalHits()
+}
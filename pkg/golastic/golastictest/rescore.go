@@ -0,0 +1,135 @@
+package golastictest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// defaultWindowSize is the number of top hits rescored when the window
+// size is not set.
+const defaultWindowSize = 10
+
+// rescorer is a query rescorer of a search.
+type rescorer struct {
+	WindowSize *int `json:"window_size"`
+	Query      struct {
+		RescoreQuery       json.RawMessage `json:"rescore_query"`
+		QueryWeight        *float64        `json:"query_weight"`
+		RescoreQueryWeight *float64        `json:"rescore_query_weight"`
+		ScoreMode          string          `json:"score_mode"`
+	} `json:"query"`
+}
+
+// rescoreHits rescores the top hits, sorted by score, with the given
+// rescorers, either a single rescorer or an array of rescorers applied
+// in order. As Elasticsearch, it rejects a sort other than by score
+// and a collapse.
+func rescoreHits(hits []*hit, raw json.RawMessage, criteria sortCriteria, collapsed bool) error {
+	if criteria != nil && (len(criteria) != 1 || !criteria.hasScore() || !criteria[0].desc) {
+		return errors.New("cannot use [sort] option in conjunction with [rescore]")
+	}
+	if collapsed {
+		return errors.New("cannot use [collapse] in conjunction with [rescore]")
+	}
+
+	var rescorers []rescorer
+	if err := json.Unmarshal(raw, &rescorers); err != nil {
+		var r rescorer
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return err
+		}
+		rescorers = []rescorer{r}
+	}
+
+	for _, r := range rescorers {
+		window := defaultWindowSize
+		if r.WindowSize != nil {
+			window = *r.WindowSize
+		}
+		if window > len(hits) {
+			window = len(hits)
+		}
+
+		for _, h := range hits[:window] {
+			ok, score, err := evaluate(r.Query.RescoreQuery, h.doc)
+			if err != nil {
+				return err
+			}
+			h.score, err = r.combine(h.score, score, ok)
+			if err != nil {
+				return err
+			}
+		}
+		sortHits(hits[:window], criteria)
+	}
+	return nil
+}
+
+// combine returns the score of a hit of the given query score once
+// rescored. Hits not matching the rescore query keep their weighted
+// query score.
+func (r rescorer) combine(score, rescore float64, matched bool) (float64, error) {
+	qw, rw := 1.0, 1.0
+	if r.Query.QueryWeight != nil {
+		qw = *r.Query.QueryWeight
+	}
+	if r.Query.RescoreQueryWeight != nil {
+		rw = *r.Query.RescoreQueryWeight
+	}
+
+	a := qw * score
+	if !matched {
+		return a, nil
+	}
+	b := rw * rescore
+
+	switch r.Query.ScoreMode {
+	case "", "total":
+		return a + b, nil
+	case "multiply":
+		return a * b, nil
+	case "avg":
+		return (a + b) / 2, nil
+	case "max":
+		return math.Max(a, b), nil
+	case "min":
+		return math.Min(a, b), nil
+	default:
+		return 0, fmt.Errorf("illegal score_mode [%s]", r.Query.ScoreMode)
+	}
+}
+
+// collapse is the collapse parameter of a search.
+type collapse struct {
+	Field     string          `json:"field"`
+	InnerHits json.RawMessage `json:"inner_hits"`
+}
+
+// collapseHits returns the sorted hits restricted to the top hit of each
+// value of the collapse field. Hits without a value are collapsed together.
+func collapseHits(hits []*hit, c collapse) ([]*hit, error) {
+	if len(c.InnerHits) != 0 {
+		return nil, unsupportedError{"collapse parameter [inner_hits]"}
+	}
+
+	seen := map[string]bool{}
+	collapsed := []*hit{}
+	for _, h := range hits {
+		vs := values(h.doc.src, c.Field)
+		if len(vs) > 1 {
+			return nil, fmt.Errorf("failed to collapse [%s], the collapse field must be single valued", c.Field)
+		}
+		key := ""
+		if len(vs) == 1 {
+			b, _ := json.Marshal(vs[0])
+			key = string(b)
+		}
+		if !seen[key] {
+			seen[key] = true
+			collapsed = append(collapsed, h)
+		}
+	}
+	return collapsed, nil
+}
@@ -0,0 +1,38 @@
+package golastictest
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// runScript applies the update script to the source of a document.
+// Only the scripts built by golastic are supported.
+func runScript(s golastic.Script, src map[string]interface{}) error {
+	switch s.Source {
+	case golastic.AppendScript("", nil).Source:
+		return appendValue(src, s.Params)
+	case golastic.RemoveScript("").Source:
+		field, _ := s.Params["field"].(string)
+		delete(src, field)
+		return nil
+	}
+	return unsupportedError{fmt.Sprintf("script [%s]", s.Source)}
+}
+
+// appendValue emulates golastic.AppendScript.
+func appendValue(src map[string]interface{}, params map[string]interface{}) error {
+	field, _ := params["field"].(string)
+	arr, ok := src[field].([]interface{})
+	if !ok {
+		return fmt.Errorf("field [%s] is not an array", field)
+	}
+	for _, v := range arr {
+		if reflect.DeepEqual(v, params["value"]) {
+			return nil
+		}
+	}
+	src[field] = append(arr, params["value"])
+	return nil
+}
@@ -0,0 +1,279 @@
+package golastictest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// defaultSize is the number of hits returned when the size is not set.
+const defaultSize = 10
+
+// searchRequest is the body of a search or count request.
+type searchRequest struct {
+	Query  json.RawMessage `json:"query"`
+	From   int             `json:"from"`
+	Size   *int            `json:"size"`
+	Sort   json.RawMessage `json:"sort"`
+	Source json.RawMessage `json:"_source"`
+
+	Rescore  json.RawMessage `json:"rescore"`
+	Collapse *collapse       `json:"collapse"`
+
+	PIT *struct {
+		ID string `json:"id"`
+	} `json:"pit"`
+	SearchAfter []interface{} `json:"search_after"`
+}
+
+// unsupportedSearchKeys are the keys of a search body the fake rejects
+// rather than silently ignore, as they change the results.
+var unsupportedSearchKeys = []string{
+	"aggs", "aggregations", "post_filter", "min_score",
+}
+
+// hit is a document matching a search.
+type hit struct {
+	index string
+	doc   *document
+	score float64
+	seq   int // Position in index order, used to break ties and sort by _doc.
+	sort  []interface{}
+}
+
+// search performs a search or a count, depending on the endpoint,
+// on the given indices or on all indices if nil.
+func (s *Server) search(w http.ResponseWriter, names []string, endpoint string, body []byte) {
+	req, err := parseSearchRequest(body, endpoint == "_count")
+	if err != nil {
+		respondSearchError(w, err)
+		return
+	}
+
+	// The query is parsed even if there are no documents to evaluate.
+	if _, _, err := evaluate(req.Query, &document{src: map[string]interface{}{}}); err != nil {
+		respondSearchError(w, err)
+		return
+	}
+
+	if req.PIT != nil {
+		name, ok := s.pits[req.PIT.ID]
+		switch {
+		case names != nil:
+			respondSearchError(w, errors.New("[indices] cannot be used with point in time"))
+			return
+		case !ok:
+			respondError(w, http.StatusNotFound, "search_context_missing_exception",
+				"No search context found for id ["+req.PIT.ID+"]")
+			return
+		}
+		names = []string{name}
+	}
+
+	if names == nil {
+		for name := range s.indices {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	hits := []*hit{}
+	seq := 0
+	for _, name := range names {
+		idx, ok := s.indices[name]
+		if !ok {
+			respondError(w, http.StatusNotFound, "index_not_found_exception", "no such index ["+name+"]")
+			return
+		}
+		for _, d := range idx.docs {
+			ok, score, err := evaluate(req.Query, d)
+			if err != nil {
+				respondSearchError(w, err)
+				return
+			}
+			if ok {
+				hits = append(hits, &hit{index: name, doc: d, score: score, seq: seq})
+			}
+			seq++
+		}
+	}
+
+	if endpoint == "_count" {
+		respondJSON(w, http.StatusOK, map[string]interface{}{"count": len(hits)})
+		return
+	}
+
+	criteria, err := parseSort(req.Sort)
+	if err != nil {
+		respondSearchError(w, err)
+		return
+	}
+	sortHits(hits, criteria)
+
+	total := len(hits)
+	if len(req.Rescore) != 0 {
+		if err := rescoreHits(hits, req.Rescore, criteria, req.Collapse != nil); err != nil {
+			respondSearchError(w, err)
+			return
+		}
+	}
+	if req.Collapse != nil {
+		if hits, err = collapseHits(hits, *req.Collapse); err != nil {
+			respondSearchError(w, err)
+			return
+		}
+	}
+	if req.SearchAfter != nil {
+		if hits, err = searchAfter(hits, criteria, req.SearchAfter, req.From); err != nil {
+			respondSearchError(w, err)
+			return
+		}
+	}
+
+	includes, excludes, err := parseSourceFilter(req.Source)
+	if err != nil {
+		respondSearchError(w, err)
+		return
+	}
+
+	size := defaultSize
+	if req.Size != nil {
+		size = *req.Size
+	}
+	page := paginate(hits, req.From, size)
+
+	out := make([]map[string]interface{}, 0, len(page))
+	for _, h := range page {
+		o := map[string]interface{}{
+			"_index":  h.index,
+			"_id":     h.doc.id,
+			"_score":  nil,
+			"_source": filterSource(h.doc.raw, h.doc.src, includes, excludes),
+		}
+		if criteria == nil || criteria.hasScore() {
+			o["_score"] = h.score
+		}
+		if criteria != nil {
+			o["sort"] = h.sort
+		}
+		out = append(out, o)
+	}
+
+	res := map[string]interface{}{
+		"took":      0,
+		"timed_out": false,
+		"hits": map[string]interface{}{
+			"total": map[string]interface{}{"value": total, "relation": "eq"},
+			"hits":  out,
+		},
+	}
+	if req.PIT != nil {
+		res["pit_id"] = req.PIT.ID
+	}
+	respondJSON(w, http.StatusOK, res)
+}
+
+// searchAfter returns the sorted hits following the given sort values.
+func searchAfter(hits []*hit, criteria sortCriteria, after []interface{}, from int) ([]*hit, error) {
+	if from > 0 {
+		return nil, errors.New("`from` parameter must be set to 0 when `search_after` is used")
+	}
+	if len(criteria) != len(after) {
+		return nil, fmt.Errorf("search_after has %d value(s) but sort has %d", len(after), len(criteria))
+	}
+
+	for i, h := range hits {
+		for k, c := range criteria {
+			n := c.compare(h.sort[k], after[k])
+			if n > 0 {
+				return hits[i:], nil
+			}
+			if n < 0 {
+				break
+			}
+		}
+	}
+	return nil, nil
+}
+
+// parseSearchRequest decodes the body of a search or count request.
+// An empty body or query matches all documents.
+func parseSearchRequest(body []byte, count bool) (searchRequest, error) {
+	var req searchRequest
+	if len(bytes.TrimSpace(body)) == 0 {
+		req.Query = json.RawMessage(`{"match_all":{}}`)
+		return req, nil
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return req, err
+	}
+	for _, k := range unsupportedSearchKeys {
+		if _, ok := keys[k]; ok {
+			return req, unsupportedError{fmt.Sprintf("search parameter [%s]", k)}
+		}
+	}
+	if count && len(keys) > 1 {
+		return req, errors.New("request body is only allowed to have a query")
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		return req, err
+	}
+	if len(req.Query) == 0 {
+		req.Query = json.RawMessage(`{"match_all":{}}`)
+	}
+	return req, nil
+}
+
+// parseSourceFilter decodes the _source parameter of a search, either
+// a pattern, a list of patterns or an object of includes and excludes.
+func parseSourceFilter(raw json.RawMessage) (includes, excludes []string, err error) {
+	if len(raw) == 0 {
+		return nil, nil, nil
+	}
+
+	var pattern string
+	if json.Unmarshal(raw, &pattern) == nil {
+		return []string{pattern}, nil, nil
+	}
+	if json.Unmarshal(raw, &includes) == nil {
+		return includes, nil, nil
+	}
+
+	var filter struct {
+		Includes []string `json:"includes"`
+		Excludes []string `json:"excludes"`
+	}
+	if err := json.Unmarshal(raw, &filter); err != nil {
+		return nil, nil, unsupportedError{fmt.Sprintf("_source [%s]", raw)}
+	}
+	return filter.Includes, filter.Excludes, nil
+}
+
+// paginate returns the hits from the given offset, up to size hits.
+func paginate(hits []*hit, from, size int) []*hit {
+	if from < 0 || from >= len(hits) || size <= 0 {
+		return nil
+	}
+	end := from + size
+	if end > len(hits) {
+		end = len(hits)
+	}
+	return hits[from:end]
+}
+
+// respondSearchError responds a 400 Bad Request for an invalid
+// or unsupported search request.
+func respondSearchError(w http.ResponseWriter, err error) {
+	errType := "parsing_exception"
+	var u unsupportedError
+	if errors.As(err, &u) {
+		errType = "unsupported_operation_exception"
+	}
+	respondError(w, http.StatusBadRequest, errType, err.Error())
+}
@@ -0,0 +1,188 @@
+package golastictest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// sortCriterion is a criterion of the sort of a search.
+type sortCriterion struct {
+	field   string
+	desc    bool
+	missing interface{} // Either "_first", "_last" or a value.
+	mode    string
+}
+
+// sortCriteria is the sort of a search. A nil sortCriteria sorts
+// by descending score.
+type sortCriteria []sortCriterion
+
+// hasScore reports whether the hits are sorted by score, in which case
+// their score is returned.
+func (c sortCriteria) hasScore() bool {
+	for _, s := range c {
+		if s.field == "_score" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSort decodes the sort of a search, which is either a single
+// criterion or an array of criteria. Each criterion is a field name,
+// or an object keyed by the field name with an order or options.
+func parseSort(raw json.RawMessage) (sortCriteria, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		items = []json.RawMessage{raw}
+	}
+
+	criteria := sortCriteria{}
+	for _, item := range items {
+		var field string
+		if json.Unmarshal(item, &field) == nil {
+			criteria = append(criteria, newSortCriterion(field, ""))
+			continue
+		}
+
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(item, &m); err != nil || len(m) != 1 {
+			return nil, fmt.Errorf("malformed sort criterion %s", item)
+		}
+		for field, v := range m {
+			if field == "_geo_distance" || field == "_script" {
+				return nil, unsupportedError{fmt.Sprintf("sort [%s]", field)}
+			}
+
+			var order string
+			if json.Unmarshal(v, &order) == nil {
+				criteria = append(criteria, newSortCriterion(field, order))
+				continue
+			}
+
+			var opts struct {
+				Order   string      `json:"order"`
+				Missing interface{} `json:"missing"`
+				Mode    string      `json:"mode"`
+			}
+			if err := json.Unmarshal(v, &opts); err != nil {
+				return nil, fmt.Errorf("malformed sort criterion %s", item)
+			}
+			c := newSortCriterion(field, opts.Order)
+			if opts.Missing != nil {
+				c.missing = opts.Missing
+			}
+			c.mode = opts.Mode
+			criteria = append(criteria, c)
+		}
+	}
+	return criteria, nil
+}
+
+// newSortCriterion returns the criterion sorting by field in the given
+// order, defaulting to descending for scores and ascending otherwise.
+func newSortCriterion(field, order string) sortCriterion {
+	desc := field == "_score"
+	switch order {
+	case "asc":
+		desc = false
+	case "desc":
+		desc = true
+	}
+	return sortCriterion{field: field, desc: desc, missing: "_last"}
+}
+
+// sortHits sorts the hits and sets their sort values. Ties are broken
+// by index order.
+func sortHits(hits []*hit, criteria sortCriteria) {
+	if criteria == nil {
+		sort.SliceStable(hits, func(i, j int) bool {
+			return hits[i].score > hits[j].score
+		})
+		return
+	}
+
+	for _, h := range hits {
+		h.sort = make([]interface{}, len(criteria))
+		for i, c := range criteria {
+			h.sort[i] = c.value(h)
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		for k, c := range criteria {
+			if n := c.compare(hits[i].sort[k], hits[j].sort[k]); n != 0 {
+				return n < 0
+			}
+		}
+		return hits[i].seq < hits[j].seq
+	})
+}
+
+// value returns the sort value of the hit for the criterion,
+// or nil if the hit has no value for the sorted field.
+func (c sortCriterion) value(h *hit) interface{} {
+	switch c.field {
+	case "_score":
+		return h.score
+	case "_doc", "_shard_doc":
+		return float64(h.seq)
+	}
+
+	// Multi-valued fields are sorted by their lowest value in ascending
+	// order, and by their highest value in descending order.
+	max := c.desc
+	switch c.mode {
+	case "min":
+		max = false
+	case "max":
+		max = true
+	}
+
+	var v interface{}
+	for _, e := range values(h.doc.src, c.field) {
+		n, ok := compare(e, v)
+		if v == nil || (ok && (n > 0) == max && n != 0) {
+			v = e
+		}
+	}
+	return v
+}
+
+// compare compares two sort values in the order of the criterion.
+// Missing values are placed according to the missing parameter.
+func (c sortCriterion) compare(a, b interface{}) int {
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return 0
+		}
+		switch c.missing {
+		case "_last":
+			if a == nil {
+				return 1
+			}
+			return -1
+		case "_first":
+			if a == nil {
+				return -1
+			}
+			return 1
+		}
+		if a == nil {
+			a = c.missing
+		} else {
+			b = c.missing
+		}
+	}
+
+	n, _ := compare(a, b)
+	if c.desc {
+		return -n
+	}
+	return n
+}
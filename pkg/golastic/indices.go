@@ -4,6 +4,10 @@
 package golastic
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -12,19 +16,36 @@ import (
 
 // SearchAPI is used to interact with indices in Elasticsearch.
 type IndicesAPI struct {
-	client *elasticsearch.Client
+	client      *elasticsearch.Client
+	instruments instruments
+}
+
+// WithContext sets the context of the requests.
+func (api *IndicesAPI) WithContext(ctx context.Context) *IndicesAPI {
+	api.instruments.ctx = ctx
+	return api
+}
+
+// WithInstrumentation notifies i around each API call.
+func (api *IndicesAPI) WithInstrumentation(i Instrumentation) *IndicesAPI {
+	api.instruments.instrumentation = i
+	return api
 }
 
 // Exists returns true when the index already exists.
-func (api IndicesAPI) Exists(index string) (bool, error) {
-	res, err := api.client.Indices.Exists([]string{index})
+func (api IndicesAPI) Exists(index string) (_ bool, err error) {
+	c := api.instruments.start("indices.exists", index)
+	defer func() { c.end(err) }()
+
+	res, err := api.client.Indices.Exists([]string{index}, api.client.Indices.Exists.WithContext(c.ctx))
+	c.response(res)
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("%w: %s", ErrUnavailable, err)
 	}
-	switch err := readErrorResponse(res); err {
-	case nil:
+	switch err := readErrorResponse(res); {
+	case err == nil:
 		return true, nil
-	case ErrNotFound:
+	case errors.Is(err, ErrNotFound):
 		return false, nil
 	default:
 		return false, fmt.Errorf("[%s] %w", res.Status(), err)
@@ -32,13 +53,18 @@ func (api IndicesAPI) Exists(index string) (bool, error) {
 }
 
 // Create creates a new index with mapping.
-func (api IndicesAPI) Create(index, mapping string) error {
+func (api IndicesAPI) Create(index, mapping string) (err error) {
+	c := api.instruments.start("indices.create", index)
+	defer func() { c.end(err) }()
+
 	res, err := api.client.Indices.Create(
 		index,
+		api.client.Indices.Create.WithContext(c.ctx),
 		api.client.Indices.Create.WithBody(strings.NewReader(mapping)),
 	)
+	c.response(res)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %s", ErrUnavailable, err)
 	}
 
 	return readErrorResponse(res)
@@ -57,3 +83,74 @@ func (api IndicesAPI) CreateIfNotExists(index, mapping string) (bool, error) {
 		return true, api.Create(index, mapping)
 	}
 }
+
+// PutMapping adds the fields of the given index mapping, as accepted by
+// Create, to the mapping of an existing index. Existing fields cannot
+// be changed.
+func (api IndicesAPI) PutMapping(index, mapping string) (err error) {
+	c := api.instruments.start("indices.put_mapping", index)
+	defer func() { c.end(err) }()
+
+	var m struct {
+		Mappings json.RawMessage `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(mapping), &m); err != nil {
+		return fmt.Errorf("%w: invalid mapping: %s", ErrUnhandled, err)
+	}
+
+	res, err := api.client.Indices.PutMapping(
+		bytes.NewReader(m.Mappings),
+		api.client.Indices.PutMapping.WithIndex(index),
+		api.client.Indices.PutMapping.WithContext(c.ctx),
+	)
+	c.response(res)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	return readErrorResponse(res)
+}
+
+// IndexStatus describes an index.
+type IndexStatus struct {
+	Name     string `json:"name"`
+	Exists   bool   `json:"exists"`
+	DocCount int    `json:"doc_count"` // Top-level documents, zero if the index does not exist.
+}
+
+// Status returns whether the index exists, and its number of documents.
+func (api IndicesAPI) Status(index string) (_ *IndexStatus, err error) {
+	c := api.instruments.start("indices.status", index)
+	defer func() { c.end(err) }()
+
+	res, err := api.client.Count(
+		api.client.Count.WithContext(c.ctx),
+		api.client.Count.WithIndex(index),
+	)
+	c.response(res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+	defer res.Body.Close()
+
+	status := &IndexStatus{Name: index}
+	switch err := readErrorResponse(res); {
+	case err == nil:
+	case errors.Is(err, ErrNotFound):
+		return status, nil
+	default:
+		return nil, fmt.Errorf("[%s] %w", res.Status(), err)
+	}
+
+	var r struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+
+	status.Exists = true
+	status.DocCount = r.Count
+	return status, nil
+}
@@ -0,0 +1,260 @@
+// This file regroups the hooks called around every API call, used to
+// trace, log or measure the requests to Elasticsearch.
+
+package golastic
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/elastic/go-elasticsearch/v7/estransport"
+)
+
+// Operation describes an API call. It is passed to an Instrumentation
+// before the call, then after the call with its outcome.
+type Operation struct {
+	// Name identifies the call, e.g. "search" or "document.index".
+	Name  string
+	Index string // Empty for calls not targeting an index.
+
+	// DocCount is the number of documents sent by a write,
+	// or the number of hits returned by a search.
+	DocCount int
+
+	StartTime time.Time
+
+	// Set once the call ends.
+	Duration   time.Duration
+	StatusCode int // Zero if no response was received, and for bulks.
+	Err        error
+
+	// Bulk holds the outcome of the documents of a bulk, nil for
+	// other operations.
+	Bulk *BulkStats
+}
+
+// BulkStats counts the documents of a bulk by outcome.
+type BulkStats struct {
+	Flushed uint64 // Indexed successfully.
+	Failed  uint64 // Not indexed.
+
+	// Retried counts the documents sent again after being rejected
+	// with a 429 Too Many Requests, once per retry.
+	Retried uint64
+}
+
+// Instrumentation is notified around every API call, for instance to
+// trace the calls. It must be safe for concurrent use.
+type Instrumentation interface {
+	// Start is called before the call. The returned context is
+	// the context of the requests sent to Elasticsearch.
+	Start(ctx context.Context, op *Operation) context.Context
+
+	// End is called after the call, with the context returned by Start.
+	End(ctx context.Context, op *Operation)
+}
+
+// MultiInstrumentation returns an Instrumentation notifying each of the
+// given instrumentations, in order. Their End is called with the context
+// returned by the Start of the last one, which derives from the others.
+func MultiInstrumentation(instrumentations ...Instrumentation) Instrumentation {
+	return multiInstrumentation(instrumentations)
+}
+
+type multiInstrumentation []Instrumentation
+
+func (m multiInstrumentation) Start(ctx context.Context, op *Operation) context.Context {
+	for _, i := range m {
+		ctx = i.Start(ctx, op)
+	}
+	return ctx
+}
+
+func (m multiInstrumentation) End(ctx context.Context, op *Operation) {
+	for i := len(m) - 1; i >= 0; i-- {
+		m[i].End(ctx, op)
+	}
+}
+
+// instruments holds the context and the instrumentation of an API.
+type instruments struct {
+	ctx             context.Context
+	instrumentation Instrumentation
+}
+
+func newInstruments(cfg ContextConfig) instruments {
+	return instruments{ctx: cfg.Context, instrumentation: cfg.Instrumentation}
+}
+
+// call is an API call in progress.
+type call struct {
+	ctx             context.Context
+	op              Operation
+	instrumentation Instrumentation
+}
+
+// start starts a call of the given operation on index.
+func (in instruments) start(name, index string) *call {
+	ctx := in.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	c := &call{
+		op:              Operation{Name: name, Index: index, StartTime: time.Now()},
+		instrumentation: in.instrumentation,
+	}
+	if c.instrumentation != nil {
+		ctx = c.instrumentation.Start(ctx, &c.op)
+	}
+	c.ctx = ctx
+	return c
+}
+
+// response records the status of the response of the call.
+func (c *call) response(res *esapi.Response) {
+	if res != nil {
+		c.op.StatusCode = res.StatusCode
+	}
+}
+
+// end ends the call with the given error. It is meant to be deferred
+// with the named error result of an API method:
+//
+//	c := api.instruments.start("search", api.index)
+//	defer func() { c.end(err) }()
+func (c *call) end(err error) {
+	c.op.Duration = time.Since(c.op.StartTime)
+	c.op.Err = err
+	if c.instrumentation != nil {
+		c.instrumentation.End(c.ctx, &c.op)
+	}
+}
+
+// InstrumentationLogger is an estransport.Logger reporting each HTTP round
+// trip of an Elasticsearch client to an Instrumentation, as an operation
+// named "http.request". The requests sent by golastic APIs carry the context
+// returned by Instrumentation.Start, so that round trips can be related to
+// their API call, e.g. as child spans.
+//
+// It is meant to be used as the Logger of an elasticsearch.Config:
+//
+//	client, err := elasticsearch.NewClient(elasticsearch.Config{
+//		Logger: golastic.InstrumentationLogger{Instrumentation: i},
+//	})
+type InstrumentationLogger struct {
+	Instrumentation Instrumentation
+}
+
+var _ estransport.Logger = InstrumentationLogger{}
+
+// LogRoundTrip reports the round trip to the instrumentation.
+func (l InstrumentationLogger) LogRoundTrip(
+	req *http.Request, res *http.Response, err error, start time.Time, dur time.Duration,
+) error {
+	op := Operation{
+		Name:      "http.request",
+		Index:     requestIndex(req),
+		StartTime: start,
+	}
+	ctx := l.Instrumentation.Start(req.Context(), &op)
+
+	op.Duration = dur
+	op.Err = err
+	if res != nil {
+		op.StatusCode = res.StatusCode
+	}
+	l.Instrumentation.End(ctx, &op)
+	return nil
+}
+
+// RequestBodyEnabled returns false: the bodies are not reported.
+func (InstrumentationLogger) RequestBodyEnabled() bool { return false }
+
+// ResponseBodyEnabled returns false: the bodies are not reported.
+func (InstrumentationLogger) ResponseBodyEnabled() bool { return false }
+
+// MultiLogger returns an estransport.Logger logging each round trip with
+// each of the given loggers, in order, e.g. to log the round trips to a
+// file while reporting them to an Instrumentation. The bodies are read
+// if any of the loggers enables them, and each logger reads its own copy.
+// It returns the first error returned by the loggers.
+func MultiLogger(loggers ...estransport.Logger) estransport.Logger {
+	return multiLogger(loggers)
+}
+
+type multiLogger []estransport.Logger
+
+func (m multiLogger) LogRoundTrip(
+	req *http.Request, res *http.Response, err error, start time.Time, dur time.Duration,
+) error {
+	var reqBody, resBody []byte
+	if m.RequestBodyEnabled() {
+		reqBody = readBody(req.Body)
+	}
+	if m.ResponseBodyEnabled() && res != nil {
+		resBody = readBody(res.Body)
+	}
+
+	var firstErr error
+	for _, l := range m {
+		r, rs := req, res
+		if reqBody != nil {
+			r = req.Clone(req.Context())
+			r.Body = io.NopCloser(bytes.NewReader(reqBody))
+		}
+		if resBody != nil {
+			c := *res
+			c.Body = io.NopCloser(bytes.NewReader(resBody))
+			rs = &c
+		}
+
+		if err := l.LogRoundTrip(r, rs, err, start, dur); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// RequestBodyEnabled reports whether any of the loggers logs request bodies.
+func (m multiLogger) RequestBodyEnabled() bool {
+	for _, l := range m {
+		if l.RequestBodyEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// ResponseBodyEnabled reports whether any of the loggers logs response bodies.
+func (m multiLogger) ResponseBodyEnabled() bool {
+	for _, l := range m {
+		if l.ResponseBodyEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody reads the given body, which may be nil.
+func readBody(body io.ReadCloser) []byte {
+	if body == nil || body == http.NoBody {
+		return nil
+	}
+	b, _ := io.ReadAll(body)
+	return b
+}
+
+// requestIndex returns the index targeted by the request, if any.
+func requestIndex(req *http.Request) string {
+	first := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+	if strings.HasPrefix(first, "_") {
+		return ""
+	}
+	return first
+}
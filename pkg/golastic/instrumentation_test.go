@@ -0,0 +1,44 @@
+package golastic_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7/estransport"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestMultiLogger(t *testing.T) {
+	var withBodies, withoutBodies bytes.Buffer
+	logger := golastic.MultiLogger(
+		&estransport.TextLogger{Output: &withBodies, EnableRequestBody: true, EnableResponseBody: true},
+		&estransport.TextLogger{Output: &withoutBodies},
+	)
+	if !logger.RequestBodyEnabled() || !logger.ResponseBodyEnabled() {
+		t.Fatal("expected bodies to be enabled")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:9200/books/_search", strings.NewReader(`{"size":1}`))
+	res := &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"took":1}`))}
+	if err := logger.LogRoundTrip(req, res, nil, time.Now(), time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, body := range []string{`{"size":1}`, `{"took":1}`} {
+		if !strings.Contains(withBodies.String(), body) {
+			t.Errorf("expected %s in log, got %q", body, withBodies.String())
+		}
+		if strings.Contains(withoutBodies.String(), body) {
+			t.Errorf("unexpected %s in log, got %q", body, withoutBodies.String())
+		}
+	}
+	if !strings.Contains(withoutBodies.String(), "/books/_search") {
+		t.Errorf("expected round trip in log, got %q", withoutBodies.String())
+	}
+}
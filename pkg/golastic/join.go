@@ -0,0 +1,83 @@
+// This file regroups the entities used to query documents related to
+// other documents, either as nested objects or through a join field.
+
+package golastic
+
+// ScoreModeNone ignores the scores of the matching nested or child documents.
+// NestedQuery and HasChildQuery also accept ScoreModeAvg, ScoreModeSum,
+// ScoreModeMax and ScoreModeMin.
+const ScoreModeNone = "none"
+
+// NestedQuery is the query matching documents whose nested objects at Path
+// match Query. Unlike objects, each nested object is matched on its own,
+// so that the conditions on several of its fields cannot be met by
+// different objects.
+type NestedQuery struct {
+	Path      string     `json:"path"`
+	Query     *Query     `json:"query"`
+	ScoreMode string     `json:"score_mode,omitempty"` // Defaults to ScoreModeAvg.
+	InnerHits *InnerHits `json:"inner_hits,omitempty"`
+}
+
+// NewNestedQuery returns a configured SearchQuery for nested queries.
+func NewNestedQuery(path string, q Query) SearchQuery {
+	sq := SearchQuery{}
+	sq.Query.Nested = &NestedQuery{Path: path, Query: &q}
+	return sq
+}
+
+// HasChildQuery is the query matching parent documents of a join field
+// having children of the given Type matching Query.
+type HasChildQuery struct {
+	Type        string     `json:"type"`
+	Query       *Query     `json:"query"`
+	ScoreMode   string     `json:"score_mode,omitempty"` // Defaults to ScoreModeNone.
+	MinChildren int        `json:"min_children,omitempty"`
+	MaxChildren int        `json:"max_children,omitempty"`
+	InnerHits   *InnerHits `json:"inner_hits,omitempty"`
+}
+
+// NewHasChildQuery returns a configured SearchQuery for has_child queries.
+func NewHasChildQuery(childType string, q Query) SearchQuery {
+	sq := SearchQuery{}
+	sq.Query.HasChild = &HasChildQuery{Type: childType, Query: &q}
+	return sq
+}
+
+// HasParentQuery is the query matching child documents of a join field
+// whose parent of the given ParentType matches Query.
+type HasParentQuery struct {
+	ParentType string     `json:"parent_type"`
+	Query      *Query     `json:"query"`
+	Score      bool       `json:"score,omitempty"` // Whether the parent score is used.
+	InnerHits  *InnerHits `json:"inner_hits,omitempty"`
+}
+
+// NewHasParentQuery returns a configured SearchQuery for has_parent queries.
+func NewHasParentQuery(parentType string, q Query) SearchQuery {
+	sq := SearchQuery{}
+	sq.Query.HasParent = &HasParentQuery{ParentType: parentType, Query: &q}
+	return sq
+}
+
+// JoinField is the value of a join field in a document. Parent is only
+// set for child documents, which must be indexed with the parent ID as
+// routing (see DocumentAPI.WithRouting).
+type JoinField struct {
+	Name   string `json:"name"`
+	Parent string `json:"parent,omitempty"`
+}
+
+// JoinMapping returns the given index mapping extended with a join field
+// named field, defining the child relations of each parent relation.
+// For instance:
+//
+//	JoinMapping(mapping, "relation", map[string][]string{
+//		"book": {"review"},
+//	})
+func JoinMapping(mapping, field string, relations map[string][]string) (string, error) {
+	return extendMapping(mapping, field, map[string]interface{}{
+		"type":      "join",
+		"relations": relations,
+	})
+}
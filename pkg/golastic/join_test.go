@@ -0,0 +1,124 @@
+package golastic_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestMarshalingNested(t *testing.T) {
+	q := golastic.NewNestedQuery("co_authors", golastic.Query{
+		Bool: &golastic.BoolQuery{
+			Must: []golastic.Query{
+				{Term: &golastic.TermQuery{Field: "co_authors.firstname.keyword", Value: "Jane"}},
+				{Term: &golastic.TermQuery{Field: "co_authors.lastname.keyword", Value: "Doe"}},
+			},
+		},
+	})
+	q.Query.Nested.ScoreMode = golastic.ScoreModeNone
+	q.Query.Nested.InnerHits = &golastic.InnerHits{}
+
+	exp := `{"query":{"nested":{"path":"co_authors","query":{"bool":{"must":[` +
+		`{"term":{"co_authors.firstname.keyword":{"value":"Jane"}}},` +
+		`{"term":{"co_authors.lastname.keyword":{"value":"Doe"}}}` +
+		`]}},"score_mode":"none","inner_hits":{}}}}`
+
+	if got := q.String(); got != exp {
+		t.Errorf("unexpected nested marshaling output: expected %s, got %s", exp, got)
+	}
+}
+
+func TestMarshalingJoin(t *testing.T) {
+	child := golastic.NewHasChildQuery("review", golastic.Query{
+		MultiMatch: golastic.MultiMatchQuery{Query: "great", Fields: []golastic.Field{{Name: "comment"}}},
+	})
+	child.Query.HasChild.ScoreMode = golastic.ScoreModeMax
+	child.Query.HasChild.MinChildren = 2
+
+	exp := `{"query":{"has_child":{"type":"review","query":{"multi_match":{"query":"great","fields":["comment"]}},` +
+		`"score_mode":"max","min_children":2}}}`
+	if got := child.String(); got != exp {
+		t.Errorf("unexpected has_child marshaling output: expected %s, got %s", exp, got)
+	}
+
+	parent := golastic.NewHasParentQuery("book", golastic.Query{IDs: &golastic.IDsQuery{Values: []string{"1"}}})
+
+	exp = `{"query":{"has_parent":{"parent_type":"book","query":{"ids":{"values":["1"]}}}}}`
+	if got := parent.String(); got != exp {
+		t.Errorf("unexpected has_parent marshaling output: expected %s, got %s", exp, got)
+	}
+}
+
+func TestJoinMapping(t *testing.T) {
+	m, err := golastic.JoinMapping(`{"mappings":{"properties":{"title":{"type":"text"}}}}`, "relation", map[string][]string{
+		"book": {"review"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := `{"mappings":{"properties":{"relation":{"relations":{"book":["review"]},"type":"join"},"title":{"type":"text"}}}}`
+	if m != exp {
+		t.Errorf("unexpected mapping: expected %s, got %s", exp, m)
+	}
+}
+
+func TestAggregations(t *testing.T) {
+	var body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"hits":{"total":{"value":3,"relation":"eq"},"hits":[]},"aggregations":{
+			"co_authors":{"doc_count":4,"lastnames":{"doc_count_error_upper_bound":0,"sum_other_doc_count":0,"buckets":[
+				{"key":"Doe","doc_count":3,"books":{"doc_count":2}},
+				{"key":"Roe","doc_count":1,"books":{"doc_count":1}}
+			]}},
+			"rating":{"value":null}
+		}}`))
+	})
+
+	res, err := golastic.Search(ctx).
+		WithAggregations(map[string]golastic.Aggregation{
+			"co_authors": {
+				Nested: &golastic.NestedAggregation{Path: "co_authors"},
+				Aggregations: map[string]golastic.Aggregation{
+					"lastnames": {
+						Terms: &golastic.TermsAggregation{Field: "co_authors.lastname.keyword"},
+						Aggregations: map[string]golastic.Aggregation{
+							"books": {ReverseNested: &golastic.ReverseNestedAggregation{}},
+						},
+					},
+				},
+			},
+			"rating": {Avg: &golastic.MetricAggregation{Field: "rating"}},
+		}).
+		MatchAllQuery(golastic.SearchPagination{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := `{"query":{"match_all":{"boost":1}},"from":0,"size":0,"sort":[{"_doc":"asc"}],"aggs":{` +
+		`"co_authors":{"nested":{"path":"co_authors"},"aggs":{"lastnames":{"terms":{"field":"co_authors.lastname.keyword"},` +
+		`"aggs":{"books":{"reverse_nested":{}}}}}},` +
+		`"rating":{"avg":{"field":"rating"}}}}`
+	if body != exp {
+		t.Errorf("unexpected body: expected %s, got %s", exp, body)
+	}
+
+	nested := res.Aggregations["co_authors"]
+	if nested.DocCount != 4 {
+		t.Errorf("unexpected nested doc count: expected 4, got %d", nested.DocCount)
+	}
+	buckets := nested.Aggregations["lastnames"].Buckets
+	if len(buckets) != 2 || buckets[0].Key != "Doe" || buckets[0].DocCount != 3 {
+		t.Fatalf("unexpected buckets: %#v", buckets)
+	}
+	if got := buckets[0].Aggregations["books"].DocCount; got != 2 {
+		t.Errorf("unexpected reverse nested doc count: expected 2, got %d", got)
+	}
+	if v := res.Aggregations["rating"].Value; v != nil {
+		t.Errorf("unexpected rating value: expected nil, got %v", *v)
+	}
+}
@@ -0,0 +1,45 @@
+package golastic
+
+import "encoding/json"
+
+// extendMapping returns the given index mapping with an additional
+// property named field.
+func extendMapping(mapping, field string, property interface{}) (string, error) {
+	return editJSON(mapping, func(m map[string]interface{}) {
+		object(object(m, "mappings"), "properties")[field] = property
+	})
+}
+
+// extendSettings returns the given index mapping with an additional setting.
+func extendSettings(mapping, setting string, value interface{}) (string, error) {
+	return editJSON(mapping, func(m map[string]interface{}) {
+		object(m, "settings")[setting] = value
+	})
+}
+
+// editJSON decodes the given JSON object, applies edit on it
+// and returns it encoded.
+func editJSON(s string, edit func(map[string]interface{})) (string, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return "", err
+	}
+
+	edit(m)
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// object returns the JSON object at key in m, creating it if necessary.
+func object(m map[string]interface{}, key string) map[string]interface{} {
+	o, ok := m[key].(map[string]interface{})
+	if !ok {
+		o = map[string]interface{}{}
+		m[key] = o
+	}
+	return o
+}
@@ -0,0 +1,62 @@
+// This file regroups the entities used to build more_like_this queries,
+// which find documents similar to given documents or texts.
+
+package golastic
+
+import "github.com/clarketm/json"
+
+// MoreLikeThisQuery is the query for finding the documents similar to
+// the Like items, based on the most relevant terms of these items.
+//
+// The documents referenced by Like items are excluded from the results
+// unless Include is true.
+type MoreLikeThisQuery struct {
+	Fields        []string           `json:"fields,omitempty"` // Defaults to all fields.
+	Like          []MoreLikeThisItem `json:"like"`
+	MinTermFreq   int                `json:"min_term_freq,omitempty"`   // Defaults to 2.
+	MinDocFreq    int                `json:"min_doc_freq,omitempty"`    // Defaults to 5.
+	MaxQueryTerms int                `json:"max_query_terms,omitempty"` // Defaults to 25.
+	Include       bool               `json:"include,omitempty"`
+}
+
+// MoreLikeThisItem is an item of a more_like_this query: either a text
+// or a document referenced by its index and ID.
+type MoreLikeThisItem struct {
+	Text  string
+	Index string
+	ID    string
+}
+
+// LikeText returns a MoreLikeThisItem for the given text.
+func LikeText(text string) MoreLikeThisItem {
+	return MoreLikeThisItem{Text: text}
+}
+
+// LikeDocument returns a MoreLikeThisItem for the document of the given
+// index and ID.
+func LikeDocument(index, id string) MoreLikeThisItem {
+	return MoreLikeThisItem{Index: index, ID: id}
+}
+
+// MarshalJSON returns the item formatted as expected by Elasticsearch:
+// a string for a text or an object for a document.
+func (i MoreLikeThisItem) MarshalJSON() ([]byte, error) {
+	if i.ID == "" {
+		return json.Marshal(i.Text)
+	}
+	return json.Marshal(map[string]string{
+		"_index": i.Index,
+		"_id":    i.ID,
+	})
+}
+
+// NewMoreLikeThisQuery returns a configured SearchQuery for more_like_this
+// queries on the given fields.
+func NewMoreLikeThisQuery(fields []string, like ...MoreLikeThisItem) SearchQuery {
+	q := SearchQuery{}
+	q.Query.MoreLikeThis = &MoreLikeThisQuery{
+		Fields: fields,
+		Like:   like,
+	}
+	return q
+}
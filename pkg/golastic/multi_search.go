@@ -0,0 +1,126 @@
+// This file regroups all entities and methods to interact with
+// Elasticseach Multi Search API.
+
+package golastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// MultiSearchQuery is a single search of a multi search request.
+type MultiSearchQuery struct {
+	// Index is the searched index. The SearchAPI index is used if empty.
+	Index      string
+	Query      SearchQuery
+	Pagination SearchPagination
+	Sort       SearchSort
+}
+
+// MultiSearchResult is the outcome of a single search of a multi search
+// request. Only one of Result or Err is set.
+type MultiSearchResult struct {
+	Result *SearchResult
+	Err    error
+}
+
+// MultiSearch performs the given queries in a single round-trip.
+// It returns one MultiSearchResult per query, in the same order.
+//
+// A non-nil error is returned only if the request as a whole fails.
+// Errors of individual queries are reported in their MultiSearchResult.
+func (api *SearchAPI) MultiSearch(queries ...MultiSearchQuery) (_ []MultiSearchResult, err error) {
+	if len(queries) == 0 {
+		return []MultiSearchResult{}, nil
+	}
+
+	c := api.instruments.start("multi_search", api.index)
+	defer func() { c.end(err) }()
+
+	payload, err := newMultiSearchBody(queries, api.index, api.opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnhandled, err)
+	}
+
+	res, err := api.client.Msearch(bytes.NewReader(payload), api.client.Msearch.WithContext(c.ctx))
+	c.response(res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to perform multi search: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	if err := readErrorResponse(res); err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Responses []multiSearchResponse `json:"responses"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+
+	if len(r.Responses) != len(queries) {
+		return nil, fmt.Errorf(
+			"%w: expected %d multi search responses, got %d",
+			ErrUnhandled, len(queries), len(r.Responses),
+		)
+	}
+
+	results := make([]MultiSearchResult, 0, len(r.Responses))
+	for _, resp := range r.Responses {
+		results = append(results, resp.unwrap())
+		c.op.DocCount += results[len(results)-1].Result.hitCount()
+	}
+
+	return results, nil
+}
+
+// newMultiSearchBody returns the newline-delimited JSON body of a multi
+// search request. Each query is preceded by a header targeting its index.
+func newMultiSearchBody(queries []MultiSearchQuery, index string, o searchOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, q := range queries {
+		header := map[string]string{"index": index}
+		if q.Index != "" {
+			header["index"] = q.Index
+		}
+
+		h, err := json.Marshal(header)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(h)
+		buf.WriteByte('\n')
+
+		s := q.Sort
+		if len(s) == 0 {
+			s = defaultSort
+		}
+		buf.Write(newSearchBody(q.Query, q.Pagination, s, o).Bytes())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// multiSearchResponse is a single response of a multi search request.
+// It holds either a search result or an error.
+type multiSearchResponse struct {
+	SearchResult
+	Status int `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error,omitempty"`
+}
+
+func (r multiSearchResponse) unwrap() MultiSearchResult {
+	if r.Error != nil {
+		return MultiSearchResult{
+			Err: fmt.Errorf("%w: %s: %s", statusError(r.Status), r.Error.Type, r.Error.Reason),
+		}
+	}
+	result := r.SearchResult
+	return MultiSearchResult{Result: &result}
+}
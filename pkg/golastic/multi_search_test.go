@@ -0,0 +1,57 @@
+package golastic_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestMultiSearch(t *testing.T) {
+	var body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"responses":[
+			{"hits":{"total":{"value":1},"hits":[{"_id":"1","_source":{}}]},"status":200},
+			{"error":{"type":"index_not_found_exception","reason":"no such index [authors]"},"status":404}
+		]}`))
+	})
+
+	results, err := golastic.Search(ctx).MultiSearch(
+		golastic.MultiSearchQuery{
+			Query:      golastic.NewMatchAllQuery(),
+			Pagination: golastic.SearchPagination{Size: 5},
+		},
+		golastic.MultiSearchQuery{
+			Index:      "authors",
+			Query:      golastic.NewMultiMatchQuery("foo", []golastic.Field{{Name: "name"}}),
+			Pagination: golastic.SearchPagination{From: 10, Size: 10},
+			Sort:       golastic.SearchSort{golastic.SortBy("_score")},
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := `{"index":"books"}
+{"query":{"match_all":{"boost":1}},"from":0,"size":5,"sort":[{"_doc":"asc"}]}
+{"index":"authors"}
+{"query":{"multi_match":{"query":"foo","fields":["name"],"operator":"and"}},"from":10,"size":10,"sort":["_score"]}
+`
+	if body != exp {
+		t.Errorf("unexpected multi search body: expected %s, got %s", exp, body)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].Result.TotalHits() != 1 {
+		t.Errorf("unexpected first result: %#v", results[0])
+	}
+	if !errors.Is(results[1].Err, golastic.ErrNotFound) {
+		t.Errorf("expected second result error to be ErrNotFound, got %v", results[1].Err)
+	}
+}
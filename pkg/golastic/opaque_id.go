@@ -0,0 +1,58 @@
+// This file regroups the forwarding of opaque IDs to Elasticsearch,
+// identifying the origin of the requests in its slow logs and tasks.
+
+package golastic
+
+import (
+	"context"
+	"net/http"
+)
+
+// OpaqueIDHeader is the header Elasticsearch reports in its slow logs
+// and tasks, to tie them back to the origin of the requests.
+const OpaqueIDHeader = "X-Opaque-Id"
+
+type opaqueIDKey struct{}
+
+// WithOpaqueID returns a copy of ctx carrying the given opaque ID,
+// typically the ID of the HTTP request being served. The requests sent
+// with the context through an OpaqueIDTransport carry it.
+func WithOpaqueID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, opaqueIDKey{}, id)
+}
+
+// OpaqueID returns the opaque ID carried by ctx, or an empty string.
+func OpaqueID(ctx context.Context) string {
+	id, _ := ctx.Value(opaqueIDKey{}).(string)
+	return id
+}
+
+// OpaqueIDTransport is an http.RoundTripper setting the X-Opaque-Id header
+// of the requests to the opaque ID of their context, if any. The context of
+// the requests is set with ContextConfig.Context.
+//
+// It is meant to be used as the Transport of an elasticsearch.Config,
+// or of a ResilientTransport:
+//
+//	t := golastic.NewResilientTransport(golastic.ResilienceConfig{
+//		Transport: golastic.OpaqueIDTransport{},
+//	})
+type OpaqueIDTransport struct {
+	// Transport sends the requests. http.DefaultTransport is used if nil.
+	Transport http.RoundTripper
+}
+
+// RoundTrip sends the request with the opaque ID of its context.
+func (t OpaqueIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := OpaqueID(req.Context()); id != "" && req.Header.Get(OpaqueIDHeader) == "" {
+		// A RoundTripper must not modify the request.
+		req = req.Clone(req.Context())
+		req.Header.Set(OpaqueIDHeader, id)
+	}
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return transport.RoundTrip(req)
+}
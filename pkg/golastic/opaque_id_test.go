@@ -0,0 +1,42 @@
+package golastic_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestOpaqueIDTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(golastic.OpaqueIDHeader)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"_id":"1","found":true,"_source":{"title":"Foo"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{srv.URL},
+		Transport: golastic.OpaqueIDTransport{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := golastic.ContextConfig{
+		Client:    client,
+		IndexName: "books",
+		Context:   golastic.WithOpaqueID(context.Background(), "request-1"),
+	}
+	if _, err := golastic.DocumentOf[book](ctx).Get("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != "request-1" {
+		t.Errorf("unexpected opaque ID: expected %q, got %q", "request-1", got)
+	}
+}
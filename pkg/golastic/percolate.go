@@ -0,0 +1,40 @@
+// This file regroups the entities used to store queries in a percolator
+// field and to match documents against them.
+
+package golastic
+
+// PercolateQuery is the query for matching a document against the queries
+// stored in a percolator field. The document is either given inline with
+// Document or Documents, or referenced by Index and ID.
+type PercolateQuery struct {
+	Field     string        `json:"field"`
+	Document  interface{}   `json:"document,omitempty"`
+	Documents []interface{} `json:"documents,omitempty"`
+	Index     string        `json:"index,omitempty"`
+	ID        string        `json:"id,omitempty"`
+}
+
+// NewPercolateQuery returns a configured SearchQuery matching the queries
+// stored in the percolator field against the given document.
+func NewPercolateQuery(field string, doc interface{}) SearchQuery {
+	q := SearchQuery{}
+	q.Query.Percolate = &PercolateQuery{
+		Field:    field,
+		Document: doc,
+	}
+	return q
+}
+
+// PercolatorMapping returns the given index mapping extended with a field
+// of type percolator, used to store queries. The mapping must define the
+// fields of the percolated documents for the stored queries to be parsed.
+//
+// Fields queried by stored queries but missing in the mapping are mapped
+// as text, consistently with Elasticsearch dynamic mapping.
+func PercolatorMapping(mapping, field string) (string, error) {
+	m, err := extendMapping(mapping, field, map[string]interface{}{"type": "percolator"})
+	if err != nil {
+		return "", err
+	}
+	return extendSettings(m, "index.percolator.map_unmapped_fields_as_text", true)
+}
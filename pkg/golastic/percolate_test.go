@@ -0,0 +1,38 @@
+package golastic_test
+
+import (
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestPercolatorMapping(t *testing.T) {
+	mapping := `{"mappings":{"properties":{"title":{"type":"text"}}}}`
+
+	got, err := golastic.PercolatorMapping(mapping, "query")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := `{"mappings":{"properties":{"query":{"type":"percolator"},"title":{"type":"text"}}},` +
+		`"settings":{"index.percolator.map_unmapped_fields_as_text":true}}`
+	if got != exp {
+		t.Errorf("unexpected mapping: expected %s, got %s", exp, got)
+	}
+
+	if _, err := golastic.PercolatorMapping("not json", "query"); err == nil {
+		t.Error("expected error for invalid mapping")
+	}
+}
+
+func TestMarshalingPercolate(t *testing.T) {
+	doc := struct {
+		Title string `json:"title"`
+	}{"Foo"}
+
+	exp := `{"query":{"percolate":{"field":"query","document":{"title":"Foo"}}}}`
+
+	if got := golastic.NewPercolateQuery("query", doc).String(); got != exp {
+		t.Errorf("unexpected percolate marshaling output: expected %s, got %s", exp, got)
+	}
+}
@@ -0,0 +1,114 @@
+// This file regroups the entities used to page through all the hits
+// of a search, namely Point in Time API and search_after.
+
+package golastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// pointInTime is the point in time of an index a search is performed on.
+type pointInTime struct {
+	ID        string `json:"id"`
+	KeepAlive string `json:"keep_alive,omitempty"`
+}
+
+// pageKeepAlive is how long the point in time of EachPage is kept alive
+// between two pages.
+const pageKeepAlive = "1m"
+
+// EachPage calls fn with each page of at most size hits matching the given
+// query, until all the hits are consumed or fn returns an error.
+//
+// The hits are searched on a point in time of the index and sorted in index
+// order, so that the changes to the index while paging, including the ones
+// made by fn, neither hide hits nor return them twice.
+func (api *SearchAPI) EachPage(q SearchQuery, size int, fn func(*SearchResult) error) (err error) {
+	pit, err := api.openPointInTime(pageKeepAlive)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := api.closePointInTime(pit.ID); err == nil {
+			err = cerr
+		}
+	}()
+
+	page := *api
+	page.opts.pit = pit
+	page.opts.searchAfter = nil
+	for {
+		res, err := page.search(q, SearchPagination{Size: size}, SearchSort{SortBy("_shard_doc")})
+		if err != nil {
+			return err
+		}
+		if res.hitCount() == 0 {
+			return nil
+		}
+		if err := fn(res); err != nil {
+			return err
+		}
+		if res.hitCount() < size {
+			return nil
+		}
+
+		// The ID of the point in time may change between searches.
+		if res.PointInTimeID != "" {
+			pit.ID = res.PointInTimeID
+		}
+		page.opts.searchAfter = res.Hits.Hits[len(res.Hits.Hits)-1].Sort
+	}
+}
+
+// openPointInTime opens a point in time of the receiver's index,
+// kept alive for the given duration, e.g. "1m".
+func (api *SearchAPI) openPointInTime(keepAlive string) (_ *pointInTime, err error) {
+	c := api.instruments.start("open_point_in_time", api.index)
+	defer func() { c.end(err) }()
+
+	res, err := api.client.OpenPointInTime(
+		api.client.OpenPointInTime.WithContext(c.ctx),
+		api.client.OpenPointInTime.WithIndex(api.index),
+		api.client.OpenPointInTime.WithKeepAlive(keepAlive),
+	)
+	c.response(res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open point in time: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	if err := readErrorResponse(res); err != nil {
+		return nil, err
+	}
+
+	pit := pointInTime{KeepAlive: keepAlive}
+	if err := json.NewDecoder(res.Body).Decode(&pit); err != nil {
+		return nil, err
+	}
+	return &pit, nil
+}
+
+// closePointInTime releases the point in time of the given ID.
+func (api *SearchAPI) closePointInTime(id string) (err error) {
+	c := api.instruments.start("close_point_in_time", api.index)
+	defer func() { c.end(err) }()
+
+	payload, err := json.Marshal(pointInTime{ID: id})
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnhandled, err)
+	}
+
+	res, err := api.client.ClosePointInTime(
+		api.client.ClosePointInTime.WithContext(c.ctx),
+		api.client.ClosePointInTime.WithBody(bytes.NewReader(payload)),
+	)
+	c.response(res)
+	if err != nil {
+		return fmt.Errorf("%w: failed to close point in time: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	return readErrorResponse(res)
+}
@@ -0,0 +1,51 @@
+// This file regroups the entities used to rescore and collapse
+// the hits of a search.
+
+package golastic
+
+// Rescore modes define how the query score and the rescore query score
+// are combined.
+const (
+	RescoreModeTotal    = "total"
+	RescoreModeMultiply = "multiply"
+	RescoreModeAvg      = "avg"
+	RescoreModeMax      = "max"
+	RescoreModeMin      = "min"
+)
+
+// Rescore is a query rescorer. It executes a secondary, usually more
+// expensive, query on the top WindowSize hits of each shard only.
+type Rescore struct {
+	WindowSize int          `json:"window_size,omitempty"` // Defaults to 10.
+	Query      RescoreQuery `json:"query"`
+}
+
+// RescoreQuery configures the rescore query of a Rescore and how its score
+// is combined with the original score. Weights default to 1 if zero.
+type RescoreQuery struct {
+	RescoreQuery       Query   `json:"rescore_query"`
+	QueryWeight        float64 `json:"query_weight,omitempty"`
+	RescoreQueryWeight float64 `json:"rescore_query_weight,omitempty"`
+	ScoreMode          string  `json:"score_mode,omitempty"` // Defaults to RescoreModeTotal.
+}
+
+// Collapse collapses the hits of a search on the values of a field.
+// The field must be a keyword or numeric field with doc values.
+type Collapse struct {
+	Field string `json:"field"`
+
+	// InnerHits expands each collapsed hit with the top hits of its group.
+	// They are returned in Hit.InnerHits by name.
+	InnerHits []InnerHits `json:"inner_hits,omitempty"`
+
+	MaxConcurrentGroupSearches int `json:"max_concurrent_group_searches,omitempty"`
+}
+
+// InnerHits configures the hits returned for each group of a Collapse,
+// or for each hit of a NestedQuery, HasChildQuery or HasParentQuery.
+type InnerHits struct {
+	Name string     `json:"name,omitempty"` // Required by Collapse. Defaults to the path or type otherwise.
+	From int        `json:"from,omitempty"`
+	Size int        `json:"size,omitempty"` // Defaults to 3.
+	Sort SearchSort `json:"sort,omitempty"`
+}
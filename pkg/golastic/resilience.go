@@ -0,0 +1,408 @@
+// This file regroups the resilience layer around Elasticsearch calls:
+// retries with exponential backoff and a circuit breaker.
+
+package golastic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxRetries is the number of retries of a request
+// used for a negative RetryConfig.MaxRetries.
+const DefaultMaxRetries = 3
+
+// RetryConfig configures the retries of idempotent requests.
+type RetryConfig struct {
+	// MaxRetries is the maximum number of retries of a request.
+	// Unlike the other fields, its zero value disables the retries
+	// so that they are opted in. A negative value uses DefaultMaxRetries.
+	MaxRetries int
+
+	// InitialBackoff is the maximum wait before the first retry, doubled
+	// for each following retry. The actual wait is picked at random up to
+	// this value. Defaults to 100ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff bounds the wait between retries. A Retry-After
+	// longer than MaxBackoff is not honoured and the response is
+	// returned as is. Defaults to 5s.
+	MaxBackoff time.Duration
+
+	// RetryOnStatus are the response statuses retried.
+	// Defaults to 429, 502 and 503.
+	RetryOnStatus []int
+}
+
+// BreakerConfig configures the circuit breaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the
+	// circuit. Network errors, 502, 503 and 504 responses are failures.
+	// Requests whose context is canceled or past its deadline are not.
+	// Defaults to 5.
+	FailureThreshold int
+
+	// OpenTimeout is how long the circuit stays open before letting
+	// a single request probe the cluster. Defaults to 30s.
+	OpenTimeout time.Duration
+}
+
+// ResilienceConfig configures a ResilientTransport.
+type ResilienceConfig struct {
+	Retry   RetryConfig
+	Breaker BreakerConfig
+
+	// Transport sends the requests. http.DefaultTransport is used if nil.
+	Transport http.RoundTripper
+}
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+// Circuit breaker states.
+const (
+	// BreakerClosed lets all requests through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails all requests fast.
+	BreakerOpen
+	// BreakerHalfOpen lets a single request probe the cluster.
+	BreakerHalfOpen
+)
+
+// String returns the state as a lowercase string, e.g. "open".
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ResilientTransport is an http.RoundTripper retrying the idempotent
+// requests failing with a transient error, with exponential backoff and
+// jitter, and failing fast while the cluster is down.
+//
+// It is meant to be used as the Transport of an elasticsearch.Config,
+// with the retries of the client disabled:
+//
+//	t := golastic.NewResilientTransport(golastic.ResilienceConfig{
+//		Retry: golastic.RetryConfig{MaxRetries: golastic.DefaultMaxRetries},
+//	})
+//	client, err := elasticsearch.NewClient(elasticsearch.Config{
+//		Transport:    t,
+//		DisableRetry: true,
+//	})
+//
+// While the circuit is open, requests fail with a 503 Service Unavailable
+// response, surfaced by the APIs as ErrUnavailable.
+type ResilientTransport struct {
+	transport http.RoundTripper
+	retry     RetryConfig
+	breaker   *breaker
+}
+
+// NewResilientTransport returns a ResilientTransport configured with cfg.
+// The zero value of each field but Retry.MaxRetries uses its default.
+func NewResilientTransport(cfg ResilienceConfig) *ResilientTransport {
+	r := cfg.Retry
+	if r.MaxRetries < 0 {
+		r.MaxRetries = DefaultMaxRetries
+	}
+	if r.InitialBackoff == 0 {
+		r.InitialBackoff = 100 * time.Millisecond
+	}
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = 5 * time.Second
+	}
+	if r.RetryOnStatus == nil {
+		r.RetryOnStatus = []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+		}
+	}
+
+	b := cfg.Breaker
+	if b.FailureThreshold == 0 {
+		b.FailureThreshold = 5
+	}
+	if b.OpenTimeout == 0 {
+		b.OpenTimeout = 30 * time.Second
+	}
+
+	t := cfg.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+
+	return &ResilientTransport{
+		transport: t,
+		retry:     r,
+		breaker:   &breaker{cfg: b},
+	}
+}
+
+// BreakerState returns the current state of the circuit breaker.
+func (t *ResilientTransport) BreakerState() BreakerState {
+	return t.breaker.currentState()
+}
+
+// RoundTrip sends the request, retrying it if it is idempotent
+// and fails with a transient error.
+func (t *ResilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := bufferBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := 0
+	if isIdempotent(req) {
+		retries = t.retry.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		if wait, ok := t.breaker.allow(); !ok {
+			return circuitOpenResponse(req, wait), nil
+		}
+
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		res, err := t.transport.RoundTrip(req)
+		if req.Context().Err() != nil {
+			// The caller gave up: the outcome says nothing about the
+			// cluster, and the request must not be retried.
+			t.breaker.release()
+			return res, err
+		}
+		t.breaker.record(isFailure(res, err))
+
+		if attempt >= retries || !t.isRetryable(res, err) {
+			return res, err
+		}
+
+		wait, ok := t.backoff(attempt, res)
+		if !ok {
+			return res, err
+		}
+		if res != nil {
+			io.Copy(io.Discard, res.Body) //nolint:errcheck // the body is dropped
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryable reports whether the attempt failed with a transient error.
+func (t *ResilientTransport) isRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	for _, s := range t.retry.RetryOnStatus {
+		if res.StatusCode == s {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the retry following the given attempt:
+// the Retry-After of the response if any, a random duration up to the
+// exponential backoff otherwise. It reports false if the Retry-After
+// exceeds the maximum backoff.
+func (t *ResilientTransport) backoff(attempt int, res *http.Response) (time.Duration, bool) {
+	if res != nil {
+		if d, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+			return d, d <= t.retry.MaxBackoff
+		}
+	}
+
+	max := t.retry.InitialBackoff << attempt
+	if max > t.retry.MaxBackoff || max <= 0 {
+		max = t.retry.MaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(max) + 1)), true //nolint:gosec // jitter needs no secure randomness
+}
+
+// retryAfter parses a Retry-After header, either in seconds or as a date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if date, err := http.ParseTime(v); err == nil {
+		d := time.Until(date)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// idempotentEndpoints are the endpoints reading data through POST requests.
+var idempotentEndpoints = []string{
+	"_search", "_count", "_msearch", "_mget", "_explain",
+	"_search/template", "_msearch/template", "_validate/query",
+}
+
+// isIdempotent reports whether the request can be safely retried. Requests
+// indexing documents with generated IDs, updates and bulks are not.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		for _, e := range idempotentEndpoints {
+			if strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), "/"+e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isFailure reports whether the attempt indicates that the cluster is down.
+func isFailure(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// bufferBody reads the body of the request so that it can be sent
+// again on retries.
+func bufferBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	return b, nil
+}
+
+// circuitOpenResponse returns the response of a request failed fast
+// while the circuit is open, shaped as an Elasticsearch error.
+func circuitOpenResponse(req *http.Request, wait time.Duration) *http.Response {
+	body := fmt.Sprintf(
+		`{"error":{"type":"circuit_open_exception","reason":"circuit breaker is open"},"status":%d}`,
+		http.StatusServiceUnavailable,
+	)
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("Retry-After", strconv.Itoa(int(wait.Round(time.Second)/time.Second)))
+
+	return &http.Response{
+		Status:        "503 Service Unavailable",
+		StatusCode:    http.StatusServiceUnavailable,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// breaker is a circuit breaker opening after consecutive failures.
+type breaker struct {
+	cfg BreakerConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool // A request is probing the cluster in half-open state.
+}
+
+// currentState returns the state of the breaker, half-open once
+// the open timeout elapsed.
+func (b *breaker) currentState() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.update()
+	return b.state
+}
+
+// update moves an open breaker to half-open once the open timeout elapsed.
+func (b *breaker) update() {
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state = BreakerHalfOpen
+		b.probing = false
+	}
+}
+
+// allow reports whether a request can be sent. If not, it returns
+// the remaining time before the circuit lets a request probe the cluster.
+func (b *breaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.update()
+
+	switch b.state {
+	case BreakerOpen:
+		return b.cfg.OpenTimeout - time.Since(b.openedAt), false
+	case BreakerHalfOpen:
+		if b.probing {
+			return 0, false
+		}
+		b.probing = true
+	}
+	return 0, true
+}
+
+// release lets another request probe the cluster if the request
+// allowed to do so ended without an outcome, e.g. as it was canceled.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// record records the outcome of a request.
+func (b *breaker) record(failure bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failure {
+		b.state = BreakerClosed
+		b.failures = 0
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		b.probing = false
+	}
+}
@@ -0,0 +1,153 @@
+package golastic_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+// newResilientContext returns a context whose client sends its requests
+// to h through a ResilientTransport configured with cfg.
+func newResilientContext(t *testing.T, cfg golastic.ResilienceConfig, h http.HandlerFunc) (golastic.ContextConfig, *golastic.ResilientTransport) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		h(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	transport := golastic.NewResilientTransport(cfg)
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses:    []string{srv.URL},
+		Transport:    transport,
+		DisableRetry: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return golastic.ContextConfig{Client: client, IndexName: "books"}, transport
+}
+
+func TestResilientTransportRetries(t *testing.T) {
+	cfg := golastic.ResilienceConfig{Retry: golastic.RetryConfig{
+		MaxRetries:     golastic.DefaultMaxRetries,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	}}
+
+	var calls int32
+	ctx, _ := newResilientContext(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"_id":"1","found":true,"_source":{"title":"Foo"}}`))
+		}
+	})
+
+	if _, err := golastic.DocumentOf[book](ctx).Get("1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 3 {
+		t.Errorf("unexpected number of calls: expected 3, got %d", calls)
+	}
+
+	// Indexing with a generated ID is not idempotent.
+	calls = 0
+	ctx, _ = newResilientContext(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	if _, err := golastic.DocumentOf[book](ctx).Index(book{Title: "Foo"}); !errors.Is(err, golastic.ErrUnavailable) {
+		t.Errorf("unexpected error: expected %s, got %v", golastic.ErrUnavailable, err)
+	}
+	if calls != 1 {
+		t.Errorf("unexpected number of calls: expected 1, got %d", calls)
+	}
+}
+
+func TestResilientTransportBreaker(t *testing.T) {
+	cfg := golastic.ResilienceConfig{
+		Breaker: golastic.BreakerConfig{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond},
+	}
+
+	var calls int32
+	var down atomic.Value
+	down.Store(true)
+	ctx, transport := newResilientContext(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if down.Load().(bool) {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"_id":"1","found":true,"_source":{"title":"Foo"}}`))
+	})
+	get := func() error {
+		_, err := golastic.DocumentOf[book](ctx).Get("1")
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := get(); !errors.Is(err, golastic.ErrUnavailable) {
+			t.Errorf("unexpected error: expected %s, got %v", golastic.ErrUnavailable, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("unexpected number of calls: expected 2, got %d", calls)
+	}
+	if s := transport.BreakerState(); s != golastic.BreakerOpen {
+		t.Errorf("unexpected breaker state: expected %s, got %s", golastic.BreakerOpen, s)
+	}
+
+	time.Sleep(cfg.Breaker.OpenTimeout)
+	if s := transport.BreakerState(); s != golastic.BreakerHalfOpen {
+		t.Errorf("unexpected breaker state: expected %s, got %s", golastic.BreakerHalfOpen, s)
+	}
+
+	down.Store(false)
+	if err := get(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if s := transport.BreakerState(); s != golastic.BreakerClosed {
+		t.Errorf("unexpected breaker state: expected %s, got %s", golastic.BreakerClosed, s)
+	}
+}
+
+func TestResilientTransportCanceledRequests(t *testing.T) {
+	cfg := golastic.ResilienceConfig{
+		Breaker: golastic.BreakerConfig{FailureThreshold: 1},
+	}
+
+	var calls int32
+	ctx, transport := newResilientContext(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-r.Context().Done() // The client hangs up first.
+	})
+
+	reqCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	ctx.Context = reqCtx
+	if _, err := golastic.DocumentOf[book](ctx).Get("1"); err == nil {
+		t.Fatal("unexpected nil error")
+	}
+
+	if calls != 1 {
+		t.Errorf("unexpected number of calls: expected 1, got %d", calls)
+	}
+	if s := transport.BreakerState(); s != golastic.BreakerClosed {
+		t.Errorf("unexpected breaker state: expected %s, got %s", golastic.BreakerClosed, s)
+	}
+}
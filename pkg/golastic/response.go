@@ -9,7 +9,30 @@ import (
 // Hit represents a single result as returned by an Elasticsearch response.
 type Hit struct {
 	ID     string          `json:"_id"`
+	Score  float64         `json:"_score"`
 	Source json.RawMessage `json:"_source"`
+
+	// Fields holds the requested stored, doc value and mapping fields.
+	// Each value is a JSON array, as returned by Elasticsearch.
+	Fields map[string]json.RawMessage `json:"fields,omitempty"`
+
+	// Explanation details the computation of the hit's score.
+	// It is only set if the search was performed with explain enabled.
+	Explanation *Explanation `json:"_explanation,omitempty"`
+
+	// Sort holds the sort values of the hit, such as the distance
+	// computed by a GeoDistanceSort.
+	Sort []interface{} `json:"sort,omitempty"`
+
+	// InnerHits holds the inner hits of a collapsed hit by name.
+	InnerHits map[string]*SearchResult `json:"inner_hits,omitempty"`
+}
+
+// Explanation is a node of the tree describing how a score is computed.
+type Explanation struct {
+	Value       float64       `json:"value"`
+	Description string        `json:"description"`
+	Details     []Explanation `json:"details,omitempty"`
 }
 
 // Unmarshaler expects an UnmarshalHit method that is used to unmarshal a Hit
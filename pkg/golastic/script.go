@@ -0,0 +1,34 @@
+package golastic
+
+// Script is an inline script as expected by Elasticsearch,
+// for instance in script_score functions.
+type Script struct {
+	Source string                 `json:"source"`
+	Lang   string                 `json:"lang,omitempty"` // Defaults to "painless".
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// appendScriptSource is the source of the scripts returned by AppendScript.
+const appendScriptSource = "if (!ctx._source[params.field].contains(params.value)) " +
+	"{ ctx._source[params.field].add(params.value) }"
+
+// removeScriptSource is the source of the scripts returned by RemoveScript.
+const removeScriptSource = "ctx._source.remove(params.field)"
+
+// RemoveScript returns the update script removing the given field
+// from the source of a document.
+func RemoveScript(field string) Script {
+	return Script{
+		Source: removeScriptSource,
+		Params: map[string]interface{}{"field": field},
+	}
+}
+
+// AppendScript returns the update script appending the given value to the
+// array field of a document, unless the array already contains it.
+func AppendScript(field string, value interface{}) Script {
+	return Script{
+		Source: appendScriptSource,
+		Params: map[string]interface{}{"field": field, "value": value},
+	}
+}
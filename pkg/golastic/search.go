@@ -13,30 +13,112 @@ import (
 
 // SearchAPI is used to search for documents in Elasticsearch.
 type SearchAPI struct {
-	client *elasticsearch.Client
-	index  string
+	client      *elasticsearch.Client
+	index       string
+	opts        searchOptions
+	instruments instruments
 }
 
-// MatchAllQuery returns the result of a query which match all documents.
-func (api *SearchAPI) MatchAllQuery(p SearchPagination) (*SearchResult, error) {
-	res, err := api.client.Search(
-		api.client.Search.WithIndex(api.index),
-		api.client.Search.WithBody(newMatchAllQuery().Reader()),
-		api.client.Search.WithSort(defaultSort...),
-		api.client.Search.WithFrom(p.From),
-		api.client.Search.WithSize(p.Size),
-		api.client.Search.WithTrackTotalHits(true),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("%w: failed to perform search: %s", ErrBadRequest, err)
-	}
+// searchOptions holds the options applied to each search of a SearchAPI.
+type searchOptions struct {
+	fetch FetchOptions
 
-	r, err := decodeSearchResults(res)
-	if err != nil {
-		return nil, err
-	}
+	// trackTotalHits is either a bool or an int. Elasticsearch counts
+	// the hits accurately up to 10,000 if nil.
+	trackTotalHits interface{}
 
-	return r, nil
+	explain bool
+	profile bool
+
+	rescore  []Rescore
+	collapse *Collapse
+
+	aggregations map[string]Aggregation
+
+	// pit and searchAfter are set by EachPage.
+	pit         *pointInTime
+	searchAfter []interface{}
+}
+
+// WithSource restricts the returned _source of each hit to the fields
+// matching includes and not matching excludes. Both accept wildcards.
+func (api *SearchAPI) WithSource(includes, excludes []string) *SearchAPI {
+	api.opts.fetch.Source = &SourceFilter{Includes: includes, Excludes: excludes}
+	return api
+}
+
+// WithStoredFields sets the stored fields returned in each hit's Fields.
+func (api *SearchAPI) WithStoredFields(fields ...string) *SearchAPI {
+	api.opts.fetch.StoredFields = fields
+	return api
+}
+
+// WithDocvalueFields sets the doc value fields returned in each hit's Fields.
+func (api *SearchAPI) WithDocvalueFields(fields ...string) *SearchAPI {
+	api.opts.fetch.DocvalueFields = fields
+	return api
+}
+
+// WithFields sets the fields retrieved from the mapping and returned
+// in each hit's Fields.
+func (api *SearchAPI) WithFields(fields ...string) *SearchAPI {
+	api.opts.fetch.Fields = fields
+	return api
+}
+
+// WithTrackTotalHits sets whether the total number of hits is counted
+// accurately. When not set, hits are counted accurately up to 10,000.
+func (api *SearchAPI) WithTrackTotalHits(track bool) *SearchAPI {
+	api.opts.trackTotalHits = track
+	return api
+}
+
+// WithTrackTotalHitsUpTo counts the total number of hits accurately up to n.
+// Past this threshold, the total is a lower bound (see TotalHits.Relation).
+func (api *SearchAPI) WithTrackTotalHitsUpTo(n int) *SearchAPI {
+	api.opts.trackTotalHits = n
+	return api
+}
+
+// WithExplain sets whether each hit is returned with an explanation
+// of its score computation (see Hit.Explanation).
+func (api *SearchAPI) WithExplain(explain bool) *SearchAPI {
+	api.opts.explain = explain
+	return api
+}
+
+// WithProfile sets whether the search result includes detailed timing
+// information about the execution of the query (see SearchResult.Profile).
+func (api *SearchAPI) WithProfile(profile bool) *SearchAPI {
+	api.opts.profile = profile
+	return api
+}
+
+// WithRescore rescores the top hits of each shard with the given rescorers,
+// applied in order. It cannot be used along with WithCollapse, nor with
+// a sort other than "_score:desc".
+func (api *SearchAPI) WithRescore(r ...Rescore) *SearchAPI {
+	api.opts.rescore = r
+	return api
+}
+
+// WithCollapse collapses the hits on the values of a field, returning
+// only the top hit of each group. It cannot be used along with WithRescore.
+func (api *SearchAPI) WithCollapse(c Collapse) *SearchAPI {
+	api.opts.collapse = &c
+	return api
+}
+
+// WithAggregations computes the given aggregations by name over the hits
+// of the search. Their results are returned in SearchResult.Aggregations.
+func (api *SearchAPI) WithAggregations(aggs map[string]Aggregation) *SearchAPI {
+	api.opts.aggregations = aggs
+	return api
+}
+
+// MatchAllQuery returns the result of a query which match all documents.
+func (api *SearchAPI) MatchAllQuery(p SearchPagination) (*SearchResult, error) {
+	return api.search(NewMatchAllQuery(), p, defaultSort)
 }
 
 // MultiMatchQuery returns the result of a query which performs
@@ -46,29 +128,103 @@ func (api *SearchAPI) MultiMatchQuery(qs string, f []Field, p SearchPagination,
 		s = defaultSort
 	}
 
-	res, err := api.client.Search(
-		api.client.Search.WithIndex(api.index),
-		api.client.Search.WithBody(newMultiMatchQuery(qs, f).Reader()),
-		api.client.Search.WithSort(s...),
-		api.client.Search.WithFrom(p.From),
-		api.client.Search.WithSize(p.Size),
-		api.client.Search.WithTrackTotalHits(true),
-	)
+	return api.search(NewMultiMatchQuery(qs, f), p, s)
+}
+
+// Query returns the result of the given query.
+func (api *SearchAPI) Query(q SearchQuery, p SearchPagination, s SearchSort) (*SearchResult, error) {
+	if len(s) == 0 {
+		s = defaultSort
+	}
+
+	return api.search(q, p, s)
+}
+
+// search performs the given query with the receiver's fetch options.
+func (api *SearchAPI) search(q SearchQuery, p SearchPagination, s SearchSort) (_ *SearchResult, err error) {
+	c := api.instruments.start("search", api.index)
+	defer func() { c.end(err) }()
+
+	opts := []func(*esapi.SearchRequest){
+		api.client.Search.WithContext(c.ctx),
+		api.client.Search.WithBody(newSearchBody(q, p, s, api.opts).Reader()),
+	}
+	// The index of a point in time cannot be set again.
+	if api.opts.pit == nil {
+		opts = append(opts, api.client.Search.WithIndex(api.index))
+	}
+
+	res, err := api.client.Search(opts...)
+	c.response(res)
 	if err != nil {
-		return nil, fmt.Errorf("%w: failed to perform search: %s", ErrBadRequest, err)
+		return nil, fmt.Errorf("%w: failed to perform search: %s", ErrUnavailable, err)
 	}
 
 	r, err := decodeSearchResults(res)
 	if err != nil {
 		return nil, err
 	}
+	c.op.DocCount = r.hitCount()
 
 	return r, nil
 }
 
+// Count returns the number of documents matching the given query.
+func (api *SearchAPI) Count(q SearchQuery) (_ int, err error) {
+	c := api.instruments.start("count", api.index)
+	defer func() { c.end(err) }()
+
+	// The Count API only accepts the query in the request body.
+	body := SearchQuery{Query: q.Query}
+
+	res, err := api.client.Count(
+		api.client.Count.WithContext(c.ctx),
+		api.client.Count.WithIndex(api.index),
+		api.client.Count.WithBody(body.Reader()),
+	)
+	c.response(res)
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to perform count: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	if err := readErrorResponse(res); err != nil {
+		return 0, err
+	}
+
+	var r struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, err
+	}
+
+	return r.Count, nil
+}
+
 // SearchResult is the result of search in Elasticsearch.
 type SearchResult struct {
 	Hits *SearchHits `json:"hits,omitempty"`
+
+	// Profile is the raw profiling tree of the search.
+	// It is only set if the search was performed with profiling enabled.
+	Profile json.RawMessage `json:"profile,omitempty"`
+
+	// Aggregations holds the result of each aggregation requested
+	// with SearchAPI.WithAggregations by name.
+	Aggregations map[string]*AggregationResult `json:"aggregations,omitempty"`
+
+	// PointInTimeID is the ID of the point in time the search was
+	// performed on, if any.
+	PointInTimeID string `json:"pit_id,omitempty"`
+}
+
+// hitCount returns the number of hits returned in the search result.
+func (r *SearchResult) hitCount() int {
+	if r == nil || r.Hits == nil {
+		return 0
+	}
+	return len(r.Hits.Hits)
 }
 
 // TotalHits conveniently returns the number of hits for a search result.
@@ -79,6 +235,15 @@ func (r *SearchResult) TotalHits() int {
 	return 0
 }
 
+// TotalHitsRelation conveniently returns the relation of the number of hits
+// for a search result, either RelationEqual or RelationGreaterOrEqual.
+func (r *SearchResult) TotalHitsRelation() string {
+	if r != nil && r.Hits != nil && r.Hits.Total != nil && r.Hits.Total.Relation != "" {
+		return r.Hits.Total.Relation
+	}
+	return RelationEqual
+}
+
 // UnwrapHits conveniently returns the response hits. Each hit is unmarshalled
 // based on the given Unmarshaler parameter and returned as an interface left
 // to be type asserted by the caller.
@@ -105,9 +270,16 @@ type SearchHits struct {
 	Hits  []*Hit     `json:"hits,omitempty"` // The actual hits returned.
 }
 
+// Relations of the total number of hits to its actual value.
+const (
+	RelationEqual          = "eq"  // The total is accurate.
+	RelationGreaterOrEqual = "gte" // The total is a lower bound.
+)
+
 // SearchHits is the total number of hits.
 type TotalHits struct {
-	Value int `json:"value"`
+	Value    int    `json:"value"`
+	Relation string `json:"relation"` // Either RelationEqual or RelationGreaterOrEqual.
 }
 
 func decodeSearchResults(res *esapi.Response) (*SearchResult, error) {
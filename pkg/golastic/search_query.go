@@ -14,29 +14,50 @@ const (
 	DefaultQueryFrom = 0
 )
 
-var defaultSort = []string{"_doc:asc"}
-
 // SearchPagination configures the pagination of an Elasticsearch search query.
 type SearchPagination struct {
 	From int
 	Size int
 }
 
-// SearchQuery configures the sort parameter of an Elasticsearch search query.
-type SearchSort []string
-
 // SearchQuery represents the body of query made to Elasticsearch
 // Search API. It is shaped as expected from Elasticsearch.
 //
 // It exposes methods for easy conversion to bytes, string or io.Reader.
 //
 // The nested field Query holds the full text query being used.
-// Only one of its fields must be used at a time.
 type SearchQuery struct {
-	Query struct {
-		MatchAll   MatchAllQuery   `json:"match_all,omitempty"`
-		MultiMatch MultiMatchQuery `json:"multi_match,omitempty"`
-	} `json:"query,omitempty"`
+	Query Query `json:"query,omitempty"`
+
+	// Fields lists the fields to retrieve from the mapping for each hit.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Query holds a single Elasticsearch query. It is shaped as expected
+// from Elasticsearch. Only one of its fields must be used at a time.
+type Query struct {
+	MatchAll   MatchAllQuery   `json:"match_all,omitempty"`
+	MultiMatch MultiMatchQuery `json:"multi_match,omitempty"`
+
+	MoreLikeThis *MoreLikeThisQuery `json:"more_like_this,omitempty"`
+
+	Bool   *BoolQuery   `json:"bool,omitempty"`
+	IDs    *IDsQuery    `json:"ids,omitempty"`
+	Exists *ExistsQuery `json:"exists,omitempty"`
+	Term   *TermQuery   `json:"term,omitempty"`
+
+	Nested    *NestedQuery    `json:"nested,omitempty"`
+	HasChild  *HasChildQuery  `json:"has_child,omitempty"`
+	HasParent *HasParentQuery `json:"has_parent,omitempty"`
+
+	GeoDistance    *GeoDistanceQuery    `json:"geo_distance,omitempty"`
+	GeoBoundingBox *GeoBoundingBoxQuery `json:"geo_bounding_box,omitempty"`
+	GeoPolygon     *GeoPolygonQuery     `json:"geo_polygon,omitempty"`
+	GeoShape       *GeoShapeQuery       `json:"geo_shape,omitempty"`
+
+	FunctionScore *FunctionScoreQuery `json:"function_score,omitempty"`
+	ScriptScore   *ScriptScoreQuery   `json:"script_score,omitempty"`
+	Percolate     *PercolateQuery     `json:"percolate,omitempty"`
 }
 
 // MatchAllQuery is the query for performing queries
@@ -50,7 +71,9 @@ type MatchAllQuery struct {
 type MultiMatchQuery struct {
 	Query    string  `json:"query,omitempty"`
 	Fields   []Field `json:"fields,omitempty"`
+	Type     string  `json:"type,omitempty"` // For instance "best_fields" (default) or "phrase".
 	Operator string  `json:"operator,omitempty"`
+	Slop     int     `json:"slop,omitempty"` // Only used by phrase types.
 }
 
 // Bytes returns the query as bytes.
@@ -113,18 +136,74 @@ func (f Field) String() string {
 	return fmt.Sprintf("%s^%d", f.Name, f.Weight)
 }
 
-// newMatchAllQuery returns a configured SearchQuery for match-all queries.
-func newMatchAllQuery() SearchQuery {
+// NewMatchAllQuery returns a configured SearchQuery for match-all queries.
+func NewMatchAllQuery() SearchQuery {
 	q := SearchQuery{}
 	q.Query.MatchAll.Boost = 1
 	return q
 }
 
-// newMultiMatchQuery returns a configured SearchQuery for multi-match queries.
-func newMultiMatchQuery(qs string, f []Field) SearchQuery {
+// NewMultiMatchQuery returns a configured SearchQuery for multi-match queries.
+func NewMultiMatchQuery(qs string, f []Field) SearchQuery {
 	q := SearchQuery{}
 	q.Query.MultiMatch.Query = qs
 	q.Query.MultiMatch.Fields = f
 	q.Query.MultiMatch.Operator = defaultOperator
 	return q
 }
+
+// searchBody is the full body of a search request. Along with the query,
+// it holds the pagination, sort and fetch options.
+type searchBody struct {
+	SearchQuery
+
+	From           int           `json:"from"`
+	Size           int           `json:"size"`
+	Sort           SearchSort    `json:"sort,omitempty"`
+	TrackTotalHits interface{}   `json:"track_total_hits,omitempty"`
+	Source         *SourceFilter `json:"_source,omitempty"`
+	StoredFields   []string      `json:"stored_fields,omitempty"`
+	DocvalueFields []string      `json:"docvalue_fields,omitempty"`
+	Explain        bool          `json:"explain,omitempty"`
+	Profile        bool          `json:"profile,omitempty"`
+	Rescore        []Rescore     `json:"rescore,omitempty"`
+	Collapse       *Collapse     `json:"collapse,omitempty"`
+
+	Aggregations map[string]Aggregation `json:"aggs,omitempty"`
+
+	PIT         *pointInTime  `json:"pit,omitempty"`
+	SearchAfter []interface{} `json:"search_after,omitempty"`
+}
+
+// newSearchBody returns a searchBody for the given query and options.
+func newSearchBody(q SearchQuery, p SearchPagination, s SearchSort, o searchOptions) searchBody {
+	q.Fields = o.fetch.Fields
+	return searchBody{
+		SearchQuery:    q,
+		From:           p.From,
+		Size:           p.Size,
+		Sort:           s,
+		TrackTotalHits: o.trackTotalHits,
+		Source:         o.fetch.Source,
+		StoredFields:   o.fetch.StoredFields,
+		DocvalueFields: o.fetch.DocvalueFields,
+		Explain:        o.explain,
+		Profile:        o.profile,
+		Rescore:        o.rescore,
+		Collapse:       o.collapse,
+		Aggregations:   o.aggregations,
+		PIT:            o.pit,
+		SearchAfter:    o.searchAfter,
+	}
+}
+
+// Bytes returns the body as bytes.
+func (b searchBody) Bytes() []byte {
+	p, _ := json.Marshal(b)
+	return p
+}
+
+// Reader returns the body as an io.Reader.
+func (b searchBody) Reader() io.Reader {
+	return bytes.NewReader(b.Bytes())
+}
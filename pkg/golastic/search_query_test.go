@@ -20,3 +20,61 @@ func TestMarshaling(t *testing.T) {
 		t.Errorf("unexpected fields marshaling output: expected %s, got %s", exp, got)
 	}
 }
+
+func TestMarshalingFields(t *testing.T) {
+	q := golastic.SearchQuery{}
+	q.Query.MatchAll.Boost = 1
+	q.Fields = []string{"title", "author.lastname"}
+
+	exp := `{"query":{"match_all":{"boost":1}},"fields":["title","author.lastname"]}`
+
+	if got := q.String(); got != exp {
+		t.Errorf("unexpected fields marshaling output: expected %s, got %s", exp, got)
+	}
+}
+
+func TestMarshalingFunctionScore(t *testing.T) {
+	q := golastic.NewFunctionScoreQuery(
+		golastic.NewMultiMatchQuery("foo", []golastic.Field{{Name: "title"}}),
+		golastic.ScoreFunction{Weight: 1},
+		golastic.ScoreFunction{
+			Gauss: &golastic.DecayFunction{
+				Field:  "created_at",
+				Origin: "now",
+				Scale:  "30d",
+				Decay:  0.5,
+			},
+		},
+		golastic.ScoreFunction{RandomScore: &golastic.RandomScoreFunction{Seed: 42, Field: "_seq_no"}},
+	)
+	q.Query.FunctionScore.ScoreMode = golastic.ScoreModeSum
+	q.Query.FunctionScore.BoostMode = golastic.BoostModeMultiply
+
+	exp := `{"query":{"function_score":{` +
+		`"query":{"multi_match":{"query":"foo","fields":["title"],"operator":"and"}},` +
+		`"functions":[` +
+		`{"weight":1},` +
+		`{"gauss":{"created_at":{"origin":"now","scale":"30d","decay":0.5}}},` +
+		`{"random_score":{"seed":42,"field":"_seq_no"}}` +
+		`],"score_mode":"sum","boost_mode":"multiply"}}}`
+
+	if got := q.String(); got != exp {
+		t.Errorf("unexpected function score marshaling output: expected %s, got %s", exp, got)
+	}
+}
+
+func TestMarshalingMoreLikeThis(t *testing.T) {
+	q := golastic.NewMoreLikeThisQuery(
+		[]string{"title", "abstract"},
+		golastic.LikeDocument("books", "1"),
+		golastic.LikeText("wizard school"),
+	)
+	q.Query.MoreLikeThis.MinTermFreq = 1
+
+	exp := `{"query":{"more_like_this":{"fields":["title","abstract"],` +
+		`"like":[{"_id":"1","_index":"books"},"wizard school"],"min_term_freq":1}}}`
+
+	if got := q.String(); got != exp {
+		t.Errorf("unexpected more like this marshaling output: expected %s, got %s", exp, got)
+	}
+}
@@ -0,0 +1,172 @@
+package golastic_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v7"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestCount(t *testing.T) {
+	var path, body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		path, body = r.URL.Path, string(b)
+		w.Write([]byte(`{"count":42}`))
+	})
+
+	count, err := golastic.Search(ctx).Count(golastic.NewMultiMatchQuery("foo", []golastic.Field{{Name: "title"}}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if exp := "/books/_count"; path != exp {
+		t.Errorf("unexpected path: expected %s, got %s", exp, path)
+	}
+	if exp := `{"query":{"multi_match":{"query":"foo","fields":["title"],"operator":"and"}}}`; body != exp {
+		t.Errorf("unexpected body: expected %s, got %s", exp, body)
+	}
+	if count != 42 {
+		t.Errorf("unexpected count: expected 42, got %d", count)
+	}
+}
+
+func TestTotalHitsRelation(t *testing.T) {
+	var body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"hits":{"total":{"value":100,"relation":"gte"},"hits":[]}}`))
+	})
+
+	res, err := golastic.Search(ctx).
+		WithTrackTotalHitsUpTo(100).
+		MatchAllQuery(golastic.SearchPagination{Size: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if exp := `{"query":{"match_all":{"boost":1}},"from":0,"size":10,"sort":[{"_doc":"asc"}],"track_total_hits":100}`; body != exp {
+		t.Errorf("unexpected body: expected %s, got %s", exp, body)
+	}
+	if res.TotalHits() != 100 || res.TotalHitsRelation() != golastic.RelationGreaterOrEqual {
+		t.Errorf("unexpected total hits: got %d (%s)", res.TotalHits(), res.TotalHitsRelation())
+	}
+}
+
+// newTestContext returns a ContextConfig targeting the index "books"
+// of a test server that responds with the given handler.
+func newTestContext(t *testing.T, h http.HandlerFunc) golastic.ContextConfig {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		h(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return golastic.ContextConfig{Client: client, IndexName: "books"}
+}
+
+func TestCollapse(t *testing.T) {
+	var body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"hits":{"total":{"value":2,"relation":"eq"},"hits":[
+			{"_id":"1","_source":{},"inner_hits":{"others":{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_id":"2","_source":{}}]}}}}
+		]}}`))
+	})
+
+	res, err := golastic.Search(ctx).
+		WithCollapse(golastic.Collapse{
+			Field:     "author.lastname.keyword",
+			InnerHits: []golastic.InnerHits{{Name: "others", Size: 5, Sort: golastic.SearchSort{golastic.SortBy("created_at").Desc()}}},
+		}).
+		MatchAllQuery(golastic.SearchPagination{Size: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := `{"query":{"match_all":{"boost":1}},"from":0,"size":10,"sort":[{"_doc":"asc"}],` +
+		`"collapse":{"field":"author.lastname.keyword","inner_hits":[{"name":"others","size":5,"sort":[{"created_at":"desc"}]}]}}`
+	if body != exp {
+		t.Errorf("unexpected body: expected %s, got %s", exp, body)
+	}
+
+	inner := res.Hits.Hits[0].InnerHits["others"]
+	if inner.TotalHits() != 1 || inner.Hits.Hits[0].ID != "2" {
+		t.Errorf("unexpected inner hits: %#v", inner)
+	}
+}
+
+func TestRescore(t *testing.T) {
+	var body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"hits":{"total":{"value":0,"relation":"eq"},"hits":[]}}`))
+	})
+
+	_, err := golastic.Search(ctx).
+		WithRescore(golastic.Rescore{
+			WindowSize: 50,
+			Query: golastic.RescoreQuery{
+				RescoreQuery: golastic.Query{
+					MultiMatch: golastic.MultiMatchQuery{Query: "foo bar", Fields: []golastic.Field{{Name: "title"}}, Type: "phrase"},
+				},
+				RescoreQueryWeight: 2,
+			},
+		}).
+		MultiMatchQuery("foo bar", []golastic.Field{{Name: "title"}}, golastic.SearchPagination{Size: 10}, golastic.SearchSort{golastic.SortBy("_score")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := `{"query":{"multi_match":{"query":"foo bar","fields":["title"],"operator":"and"}},"from":0,"size":10,"sort":["_score"],` +
+		`"rescore":[{"window_size":50,"query":{"rescore_query":{"multi_match":{"query":"foo bar","fields":["title"],"type":"phrase"}},"rescore_query_weight":2}}]}`
+	if body != exp {
+		t.Errorf("unexpected body: expected %s, got %s", exp, body)
+	}
+}
+
+func TestExplainAndProfile(t *testing.T) {
+	var body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"hits":{"total":{"value":1,"relation":"eq"},"hits":[
+			{"_id":"1","_score":1.5,"_source":{},"_explanation":{"value":1.5,"description":"sum of:","details":[{"value":1.5,"description":"weight(title:foo)"}]}}
+		]},"profile":{"shards":[]}}`))
+	})
+
+	res, err := golastic.Search(ctx).
+		WithExplain(true).
+		WithProfile(true).
+		MatchAllQuery(golastic.SearchPagination{Size: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := `{"query":{"match_all":{"boost":1}},"from":0,"size":10,"sort":[{"_doc":"asc"}],"explain":true,"profile":true}`
+	if body != exp {
+		t.Errorf("unexpected body: expected %s, got %s", exp, body)
+	}
+
+	e := res.Hits.Hits[0].Explanation
+	if e == nil || e.Value != 1.5 || len(e.Details) != 1 || e.Details[0].Description != "weight(title:foo)" {
+		t.Errorf("unexpected explanation: %#v", e)
+	}
+	if exp := `{"shards":[]}`; string(res.Profile) != exp {
+		t.Errorf("unexpected profile: expected %s, got %s", exp, res.Profile)
+	}
+}
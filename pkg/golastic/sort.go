@@ -0,0 +1,198 @@
+// This file regroups the entities used to sort the hits of a search.
+
+package golastic
+
+import "github.com/clarketm/json"
+
+// Sort orders.
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// Missing values, defining where the hits without a value for the sorted
+// field are placed. Any other value is used as the value of these hits.
+const (
+	SortMissingFirst = "_first"
+	SortMissingLast  = "_last"
+)
+
+// Sort modes define which value of a multi-valued field is used to sort.
+const (
+	SortModeMin    = "min"
+	SortModeMax    = "max"
+	SortModeSum    = "sum"
+	SortModeAvg    = "avg"
+	SortModeMedian = "median"
+)
+
+// Sort is a sort criterion of a search. It is either a FieldSort,
+// a ScriptSort or a GeoDistanceSort.
+type Sort interface {
+	json.Marshaler
+
+	// sort restricts the implementations to the criteria
+	// supported by this package.
+	sort()
+}
+
+// SearchSort configures the sort of a search, criteria after criteria.
+// The sort values of each hit are returned in Hit.Sort.
+type SearchSort []Sort
+
+var defaultSort = SearchSort{SortByDoc()}
+
+// FieldSort sorts the hits by the values of a field, or by score
+// for the "_score" field.
+type FieldSort struct {
+	Field        string
+	Order        string      // Defaults to SortAsc, or SortDesc for "_score".
+	Missing      interface{} // Defaults to SortMissingLast.
+	Mode         string      // For multi-valued fields.
+	UnmappedType string      // Type of the field in the indices where it is unmapped.
+	Nested       *NestedSort // For fields of nested objects.
+}
+
+// NestedSort configures the nested objects used to sort by one
+// of their fields.
+type NestedSort struct {
+	Path   string `json:"path"`
+	Filter *Query `json:"filter,omitempty"` // Only the matching objects are used.
+}
+
+// SortBy returns a FieldSort sorting by the given field in ascending order.
+// For instance:
+//
+//	SortBy("created_at").Desc().WithMissing(SortMissingFirst)
+func SortBy(field string) FieldSort {
+	return FieldSort{Field: field}
+}
+
+// SortByScore returns a FieldSort sorting by descending score.
+func SortByScore() FieldSort {
+	return FieldSort{Field: "_score", Order: SortDesc}
+}
+
+// SortByDoc returns a FieldSort sorting by index order,
+// which is the most efficient sort.
+func SortByDoc() FieldSort {
+	return FieldSort{Field: "_doc", Order: SortAsc}
+}
+
+// Asc returns the sort in ascending order.
+func (s FieldSort) Asc() FieldSort {
+	s.Order = SortAsc
+	return s
+}
+
+// Desc returns the sort in descending order.
+func (s FieldSort) Desc() FieldSort {
+	s.Order = SortDesc
+	return s
+}
+
+// WithMissing returns the sort with the given missing value.
+func (s FieldSort) WithMissing(missing interface{}) FieldSort {
+	s.Missing = missing
+	return s
+}
+
+// WithMode returns the sort with the given mode.
+func (s FieldSort) WithMode(mode string) FieldSort {
+	s.Mode = mode
+	return s
+}
+
+// WithUnmappedType returns the sort with the given unmapped type.
+func (s FieldSort) WithUnmappedType(t string) FieldSort {
+	s.UnmappedType = t
+	return s
+}
+
+// WithNested returns the sort using the nested objects at path.
+func (s FieldSort) WithNested(path string) FieldSort {
+	s.Nested = &NestedSort{Path: path}
+	return s
+}
+
+// MarshalJSON returns the sort keyed by its field, as expected
+// by Elasticsearch. The shortest syntax is used. For example:
+//
+//	SortBy("title") // "title"
+//	SortBy("created_at").Desc() // {"created_at":"desc"}
+//	SortBy("created_at").Desc().WithMissing(SortMissingFirst) // {"created_at":{"order":"desc","missing":"_first"}}
+func (s FieldSort) MarshalJSON() ([]byte, error) {
+	if s.Missing == nil && s.Mode == "" && s.UnmappedType == "" && s.Nested == nil {
+		if s.Order == "" {
+			return json.Marshal(s.Field)
+		}
+		return json.Marshal(map[string]string{s.Field: s.Order})
+	}
+	return json.Marshal(map[string]interface{}{
+		s.Field: struct {
+			Order        string      `json:"order,omitempty"`
+			Missing      interface{} `json:"missing,omitempty"`
+			Mode         string      `json:"mode,omitempty"`
+			UnmappedType string      `json:"unmapped_type,omitempty"`
+			Nested       *NestedSort `json:"nested,omitempty"`
+		}{s.Order, s.Missing, s.Mode, s.UnmappedType, s.Nested},
+	})
+}
+
+func (FieldSort) sort() {}
+
+// ScriptSort sorts the hits by the values computed by a script.
+type ScriptSort struct {
+	Type   string `json:"type"` // Either "number" or "string".
+	Script Script `json:"script"`
+	Order  string `json:"order,omitempty"`
+}
+
+// SortByScript returns a ScriptSort sorting in ascending order by the
+// values of the given type computed by the script.
+func SortByScript(script Script, valueType string) ScriptSort {
+	return ScriptSort{Type: valueType, Script: script}
+}
+
+// Asc returns the sort in ascending order.
+func (s ScriptSort) Asc() ScriptSort {
+	s.Order = SortAsc
+	return s
+}
+
+// Desc returns the sort in descending order.
+func (s ScriptSort) Desc() ScriptSort {
+	s.Order = SortDesc
+	return s
+}
+
+// MarshalJSON returns the sort shaped as expected by Elasticsearch:
+//
+//	{"_script":{"type":"number","script":{"source":"..."},"order":"desc"}}
+func (s ScriptSort) MarshalJSON() ([]byte, error) {
+	type scriptSort ScriptSort // prevents infinite recursion
+	return json.Marshal(map[string]scriptSort{"_script": scriptSort(s)})
+}
+
+func (ScriptSort) sort() {}
+
+// SortByDistance returns a GeoDistanceSort sorting by ascending
+// distance of the geo_point field to origin.
+func SortByDistance(field string, origin GeoPoint) GeoDistanceSort {
+	return GeoDistanceSort{Field: field, Origin: origin}
+}
+
+// Desc returns the sort in descending order.
+func (s GeoDistanceSort) Desc() GeoDistanceSort {
+	s.Order = SortDesc
+	return s
+}
+
+// WithUnit returns the sort with distances computed in the given unit,
+// e.g. "km".
+func (s GeoDistanceSort) WithUnit(unit string) GeoDistanceSort {
+	s.Unit = unit
+	return s
+}
+
+func (GeoDistanceSort) sort() {}
@@ -0,0 +1,36 @@
+package golastic_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestMarshalingSort(t *testing.T) {
+	s := golastic.SearchSort{
+		golastic.SortByScore(),
+		golastic.SortBy("title.keyword"),
+		golastic.SortBy("created_at").Desc().WithMissing(golastic.SortMissingFirst).WithUnmappedType("date"),
+		golastic.SortBy("co_authors.lastname.keyword").WithMode(golastic.SortModeMin).WithNested("co_authors"),
+		golastic.SortByScript(golastic.Script{Source: "doc['rating'].value * 2"}, "number").Desc(),
+		golastic.SortByDistance("location", golastic.GeoPoint{Lat: 48.85, Lon: 2.35}).WithUnit("km"),
+		golastic.SortByDoc(),
+	}
+
+	exp := `[{"_score":"desc"},` +
+		`"title.keyword",` +
+		`{"created_at":{"order":"desc","missing":"_first","unmapped_type":"date"}},` +
+		`{"co_authors.lastname.keyword":{"mode":"min","nested":{"path":"co_authors"}}},` +
+		`{"_script":{"type":"number","script":{"source":"doc['rating'].value * 2"},"order":"desc"}},` +
+		`{"_geo_distance":{"location":{"lat":48.85,"lon":2.35},"unit":"km"}},` +
+		`{"_doc":"asc"}]`
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := string(b); got != exp {
+		t.Errorf("unexpected sort marshaling output: expected %s, got %s", exp, got)
+	}
+}
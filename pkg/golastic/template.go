@@ -0,0 +1,217 @@
+// This file regroups all entities and methods to interact with
+// Elasticseach search templates, namely Stored Scripts, Render Search
+// Template and Search Template APIs.
+
+package golastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+// TemplatesAPI is used to manage search templates stored in Elasticsearch.
+//
+// A search template is a mustache template rendering the body of a search
+// request from TemplateParams.
+type TemplatesAPI struct {
+	client      *elasticsearch.Client
+	instruments instruments
+}
+
+// TemplateParams holds the parameters of a search template by name.
+// Values are marshaled to JSON, so that the template can iterate over
+// slices and access the fields of structs with json tags.
+type TemplateParams map[string]interface{}
+
+// WithContext sets the context of the requests.
+func (api *TemplatesAPI) WithContext(ctx context.Context) *TemplatesAPI {
+	api.instruments.ctx = ctx
+	return api
+}
+
+// WithInstrumentation notifies i around each API call.
+func (api *TemplatesAPI) WithInstrumentation(i Instrumentation) *TemplatesAPI {
+	api.instruments.instrumentation = i
+	return api
+}
+
+// Put stores the given mustache source as the search template id,
+// replacing the existing one if any.
+func (api TemplatesAPI) Put(id, source string) (err error) {
+	c := api.instruments.start("template.put", "")
+	defer func() { c.end(err) }()
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"script": map[string]string{
+			"lang":   "mustache",
+			"source": source,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnhandled, err)
+	}
+
+	res, err := api.client.PutScript(id, bytes.NewReader(payload), api.client.PutScript.WithContext(c.ctx))
+	c.response(res)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	return readErrorResponse(res)
+}
+
+// Exists returns true when the search template id is stored.
+func (api TemplatesAPI) Exists(id string) (_ bool, err error) {
+	c := api.instruments.start("template.exists", "")
+	defer func() { c.end(err) }()
+
+	res, err := api.client.GetScript(id, api.client.GetScript.WithContext(c.ctx))
+	c.response(res)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	switch err := readErrorResponse(res); {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrNotFound):
+		return false, nil
+	default:
+		return false, fmt.Errorf("[%s] %w", res.Status(), err)
+	}
+}
+
+// PutIfNotExists stores the given mustache source as the search template id
+// if it is not stored yet. It returns true if the template is being stored.
+func (api TemplatesAPI) PutIfNotExists(id, source string) (bool, error) {
+	exists, err := api.Exists(id)
+	switch {
+	case err != nil:
+		return false, err
+	case exists:
+		return false, nil
+	default:
+		return true, api.Put(id, source)
+	}
+}
+
+// Delete removes the search template id.
+func (api TemplatesAPI) Delete(id string) (err error) {
+	c := api.instruments.start("template.delete", "")
+	defer func() { c.end(err) }()
+
+	res, err := api.client.DeleteScript(id, api.client.DeleteScript.WithContext(c.ctx))
+	c.response(res)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	return readErrorResponse(res)
+}
+
+// Render returns the search request body rendered by the search template id
+// with the given parameters, without executing it.
+func (api TemplatesAPI) Render(id string, params TemplateParams) (_ json.RawMessage, err error) {
+	c := api.instruments.start("template.render", "")
+	defer func() { c.end(err) }()
+
+	payload, err := templateBody(id, params, searchOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := api.client.RenderSearchTemplate(
+		api.client.RenderSearchTemplate.WithContext(c.ctx),
+		api.client.RenderSearchTemplate.WithBody(bytes.NewReader(payload)),
+	)
+	c.response(res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	if err := readErrorResponse(res); err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		TemplateOutput json.RawMessage `json:"template_output"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+
+	return r.TemplateOutput, nil
+}
+
+// Template returns the result of the search rendered by the stored search
+// template id with the given parameters.
+//
+// The search is entirely defined by the template: only the explain
+// and profile options of the receiver are applied, and its fetch options
+// are ignored. It returns an error wrapping ErrBadRequest if the receiver
+// has options changing the results, such as WithTrackTotalHits, WithRescore,
+// WithCollapse or WithAggregations, which must be set by the template.
+func (api *SearchAPI) Template(id string, params TemplateParams) (_ *SearchResult, err error) {
+	c := api.instruments.start("search_template", api.index)
+	defer func() { c.end(err) }()
+
+	if o := api.opts; o.trackTotalHits != nil || o.rescore != nil || o.collapse != nil || o.aggregations != nil {
+		return nil, fmt.Errorf("%w: search options must be set by the search template %s", ErrBadRequest, id)
+	}
+
+	payload, err := templateBody(id, params, api.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := api.client.SearchTemplate(
+		bytes.NewReader(payload),
+		api.client.SearchTemplate.WithContext(c.ctx),
+		api.client.SearchTemplate.WithIndex(api.index),
+	)
+	c.response(res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to perform search: %s", ErrUnavailable, err)
+	}
+
+	r, err := decodeSearchResults(res)
+	if err != nil {
+		return nil, err
+	}
+	c.op.DocCount = r.hitCount()
+
+	return r, nil
+}
+
+// templateBody returns the body of a request using the stored search
+// template id with the given parameters.
+func templateBody(id string, params TemplateParams, o searchOptions) ([]byte, error) {
+	if params == nil {
+		params = TemplateParams{}
+	}
+
+	b, err := json.Marshal(struct {
+		ID      string         `json:"id"`
+		Params  TemplateParams `json:"params"`
+		Explain bool           `json:"explain,omitempty"`
+		Profile bool           `json:"profile,omitempty"`
+	}{
+		ID:      id,
+		Params:  params,
+		Explain: o.explain,
+		Profile: o.profile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnhandled, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,61 @@
+package golastic_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestTemplate(t *testing.T) {
+	var path, body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		path, body = r.URL.Path, string(b)
+		w.Write([]byte(`{"hits":{"total":{"value":1,"relation":"eq"},"hits":[{"_id":"1","_source":{}}]}}`))
+	})
+
+	params := golastic.TemplateParams{"query": "foo", "size": 10}
+
+	res, err := golastic.Search(ctx).WithExplain(true).Template("books-search", params)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if exp := "/books/_search/template"; path != exp {
+		t.Errorf("unexpected path: expected %s, got %s", exp, path)
+	}
+	if exp := `{"id":"books-search","params":{"query":"foo","size":10},"explain":true}`; body != exp {
+		t.Errorf("unexpected body: expected %s, got %s", exp, body)
+	}
+	if res.TotalHits() != 1 {
+		t.Errorf("unexpected total hits: expected 1, got %d", res.TotalHits())
+	}
+
+	_, err = golastic.Search(ctx).WithTrackTotalHits(true).Template("books-search", params)
+	if !errors.Is(err, golastic.ErrBadRequest) {
+		t.Errorf("unexpected error: expected %s, got %v", golastic.ErrBadRequest, err)
+	}
+}
+
+func TestTemplatesPut(t *testing.T) {
+	var method, path, body string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(b)
+		w.Write([]byte(`{"acknowledged":true}`))
+	})
+
+	if err := golastic.Templates(ctx.Client).Put("books-search", `{"query":{"match":{"title":"{{query}}"}}}`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if method != http.MethodPut || path != "/_scripts/books-search" {
+		t.Errorf("unexpected request: %s %s", method, path)
+	}
+	if exp := `{"script":{"lang":"mustache","source":"{\"query\":{\"match\":{\"title\":\"{{query}}\"}}}"}}`; body != exp {
+		t.Errorf("unexpected body: expected %s, got %s", exp, body)
+	}
+}
@@ -0,0 +1,257 @@
+// This file regroups the type-safe variants of the Document and Search
+// APIs. They decode the documents into values of a given type instead of
+// relying on an Unmarshaler.
+
+package golastic
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MetadataSetter is implemented by documents receiving the metadata of
+// their hit once decoded by a typed API, such as their ID.
+// It must be implemented on the pointer receiver.
+type MetadataSetter interface {
+	SetMetadata(h Hit)
+}
+
+// decodeHit returns the source of the hit decoded as a T,
+// along with its metadata if T implements MetadataSetter.
+func decodeHit[T any](h Hit) (T, error) {
+	var doc T
+	if err := json.Unmarshal(h.Source, &doc); err != nil {
+		return doc, fmt.Errorf("%w: cannot decode document %s: %s", ErrUnhandled, h.ID, err)
+	}
+	if s, ok := interface{}(&doc).(MetadataSetter); ok {
+		s.SetMetadata(h)
+	}
+	return doc, nil
+}
+
+// TypedDocumentAPI is used to interact with documents of type T
+// in Elasticsearch.
+type TypedDocumentAPI[T any] struct {
+	api *DocumentAPI
+}
+
+// DocumentOf interfaces Elasticsearch Document API for documents of type T.
+func DocumentOf[T any](cfg ContextConfig) *TypedDocumentAPI[T] {
+	return &TypedDocumentAPI[T]{api: Document(cfg)}
+}
+
+// WithRouting routes the requests to the shard of the given routing value
+// (see DocumentAPI.WithRouting).
+func (api *TypedDocumentAPI[T]) WithRouting(routing string) *TypedDocumentAPI[T] {
+	api.api.WithRouting(routing)
+	return api
+}
+
+// WithSource restricts the returned _source to the fields matching includes
+// and not matching excludes. Both accept wildcards.
+func (api *TypedDocumentAPI[T]) WithSource(includes, excludes []string) *TypedDocumentAPI[T] {
+	api.api.WithSource(includes, excludes)
+	return api
+}
+
+// WithStoredFields sets the stored fields returned in the hit's Fields.
+func (api *TypedDocumentAPI[T]) WithStoredFields(fields ...string) *TypedDocumentAPI[T] {
+	api.api.WithStoredFields(fields...)
+	return api
+}
+
+// Get returns the document of the given ID. It returns an error wrapping
+// ErrNotFound if the document does not exist.
+func (api *TypedDocumentAPI[T]) Get(id string) (T, error) {
+	var doc T
+	res, err := api.api.Get(id)
+	if err != nil {
+		return doc, err
+	}
+	if !res.Found {
+		return doc, fmt.Errorf("%w: document %s", ErrNotFound, id)
+	}
+	return decodeHit[T](res.Hit)
+}
+
+// Index indexes a new document and returns its ID.
+func (api *TypedDocumentAPI[T]) Index(doc T) (string, error) {
+	res, err := api.api.Index(doc)
+	if err != nil {
+		return "", err
+	}
+	return res.Unwrap()
+}
+
+// Update partially updates the document of the given ID with doc, as
+// the "doc" of an update request: the fields of the JSON encoding of doc
+// replace the stored ones, and objects are merged. Zero-valued fields of
+// T without omitempty are encoded, thus overwrite the stored values.
+func (api *TypedDocumentAPI[T]) Update(id string, doc T) error {
+	return api.api.Update(id, doc)
+}
+
+// UpdateByScript updates the document of the given ID with a script.
+func (api *TypedDocumentAPI[T]) UpdateByScript(id string, s Script) error {
+	return api.api.UpdateByScript(id, s)
+}
+
+// UpdateByQuery updates the documents matching q with a script.
+func (api *TypedDocumentAPI[T]) UpdateByQuery(q SearchQuery, s Script) (int, error) {
+	return api.api.UpdateByQuery(q, s)
+}
+
+// DeleteByQuery deletes the documents matching q.
+func (api *TypedDocumentAPI[T]) DeleteByQuery(q SearchQuery) (int, error) {
+	return api.api.DeleteByQuery(q)
+}
+
+// Delete deletes the document of the given ID.
+func (api *TypedDocumentAPI[T]) Delete(id string) error {
+	return api.api.Delete(id)
+}
+
+// Bulk indexes many documents at once.
+func (api *TypedDocumentAPI[T]) Bulk(docs []T) error {
+	in := make([]interface{}, len(docs))
+	for i, doc := range docs {
+		in[i] = doc
+	}
+	return api.api.Bulk(in)
+}
+
+// BulkUpdateByScript updates many documents at once with a script.
+func (api *TypedDocumentAPI[T]) BulkUpdateByScript(ids []string, s Script) error {
+	return api.api.BulkUpdateByScript(ids, s)
+}
+
+// TypedSearchAPI is used to search documents of type T in Elasticsearch.
+type TypedSearchAPI[T any] struct {
+	api *SearchAPI
+}
+
+// SearchOf interfaces Elasticsearch Search API for documents of type T.
+func SearchOf[T any](cfg ContextConfig) *TypedSearchAPI[T] {
+	return &TypedSearchAPI[T]{api: Search(cfg)}
+}
+
+// TypedSearchResult is the result of a search of documents of type T.
+// The embedded SearchResult holds the hits and their metadata, in the
+// same order as Documents.
+type TypedSearchResult[T any] struct {
+	*SearchResult
+	Documents []T
+}
+
+// WithSource is the typed variant of SearchAPI.WithSource.
+func (api *TypedSearchAPI[T]) WithSource(includes, excludes []string) *TypedSearchAPI[T] {
+	api.api.WithSource(includes, excludes)
+	return api
+}
+
+// WithStoredFields is the typed variant of SearchAPI.WithStoredFields.
+func (api *TypedSearchAPI[T]) WithStoredFields(fields ...string) *TypedSearchAPI[T] {
+	api.api.WithStoredFields(fields...)
+	return api
+}
+
+// WithDocvalueFields is the typed variant of SearchAPI.WithDocvalueFields.
+func (api *TypedSearchAPI[T]) WithDocvalueFields(fields ...string) *TypedSearchAPI[T] {
+	api.api.WithDocvalueFields(fields...)
+	return api
+}
+
+// WithFields is the typed variant of SearchAPI.WithFields.
+func (api *TypedSearchAPI[T]) WithFields(fields ...string) *TypedSearchAPI[T] {
+	api.api.WithFields(fields...)
+	return api
+}
+
+// WithTrackTotalHits is the typed variant of SearchAPI.WithTrackTotalHits.
+func (api *TypedSearchAPI[T]) WithTrackTotalHits(track bool) *TypedSearchAPI[T] {
+	api.api.WithTrackTotalHits(track)
+	return api
+}
+
+// WithTrackTotalHitsUpTo is the typed variant of SearchAPI.WithTrackTotalHitsUpTo.
+func (api *TypedSearchAPI[T]) WithTrackTotalHitsUpTo(n int) *TypedSearchAPI[T] {
+	api.api.WithTrackTotalHitsUpTo(n)
+	return api
+}
+
+// WithExplain is the typed variant of SearchAPI.WithExplain.
+func (api *TypedSearchAPI[T]) WithExplain(explain bool) *TypedSearchAPI[T] {
+	api.api.WithExplain(explain)
+	return api
+}
+
+// WithProfile is the typed variant of SearchAPI.WithProfile.
+func (api *TypedSearchAPI[T]) WithProfile(profile bool) *TypedSearchAPI[T] {
+	api.api.WithProfile(profile)
+	return api
+}
+
+// WithRescore is the typed variant of SearchAPI.WithRescore.
+func (api *TypedSearchAPI[T]) WithRescore(r ...Rescore) *TypedSearchAPI[T] {
+	api.api.WithRescore(r...)
+	return api
+}
+
+// WithCollapse is the typed variant of SearchAPI.WithCollapse.
+func (api *TypedSearchAPI[T]) WithCollapse(c Collapse) *TypedSearchAPI[T] {
+	api.api.WithCollapse(c)
+	return api
+}
+
+// WithAggregations is the typed variant of SearchAPI.WithAggregations.
+func (api *TypedSearchAPI[T]) WithAggregations(aggs map[string]Aggregation) *TypedSearchAPI[T] {
+	api.api.WithAggregations(aggs)
+	return api
+}
+
+// MatchAllQuery returns the result of a query which match all documents.
+func (api *TypedSearchAPI[T]) MatchAllQuery(p SearchPagination) (*TypedSearchResult[T], error) {
+	return typedResult[T](api.api.MatchAllQuery(p))
+}
+
+// MultiMatchQuery returns the result of a query which performs
+// a full text search on multiple fields.
+func (api *TypedSearchAPI[T]) MultiMatchQuery(qs string, f []Field, p SearchPagination, s SearchSort) (*TypedSearchResult[T], error) {
+	return typedResult[T](api.api.MultiMatchQuery(qs, f, p, s))
+}
+
+// Query returns the result of the given query.
+func (api *TypedSearchAPI[T]) Query(q SearchQuery, p SearchPagination, s SearchSort) (*TypedSearchResult[T], error) {
+	return typedResult[T](api.api.Query(q, p, s))
+}
+
+// Template returns the result of the stored search template of the given ID
+// rendered with params.
+func (api *TypedSearchAPI[T]) Template(id string, params TemplateParams) (*TypedSearchResult[T], error) {
+	return typedResult[T](api.api.Template(id, params))
+}
+
+// Count returns the number of documents matching the given query.
+func (api *TypedSearchAPI[T]) Count(q SearchQuery) (int, error) {
+	return api.api.Count(q)
+}
+
+// typedResult returns the given search result with its hits decoded as T.
+func typedResult[T any](res *SearchResult, err error) (*TypedSearchResult[T], error) {
+	if err != nil {
+		return nil, err
+	}
+
+	r := &TypedSearchResult[T]{SearchResult: res, Documents: []T{}}
+	if res.Hits == nil {
+		return r, nil
+	}
+	for _, h := range res.Hits.Hits {
+		doc, err := decodeHit[T](*h)
+		if err != nil {
+			return nil, err
+		}
+		r.Documents = append(r.Documents, doc)
+	}
+	return r, nil
+}
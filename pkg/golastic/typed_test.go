@@ -0,0 +1,73 @@
+package golastic_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+type book struct {
+	ID    string `json:"-"`
+	Title string `json:"title"`
+	Score float64
+}
+
+func (b *book) SetMetadata(h golastic.Hit) {
+	b.ID = h.ID
+	b.Score = h.Score
+}
+
+func TestTypedDocumentGet(t *testing.T) {
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/books/_doc/1":
+			w.Write([]byte(`{"_id":"1","found":true,"_source":{"title":"Foo"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"_id":"2","found":false}`))
+		}
+	})
+
+	b, err := golastic.DocumentOf[book](ctx).Get("1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b.ID != "1" || b.Title != "Foo" {
+		t.Errorf("unexpected book: %#v", b)
+	}
+
+	if _, err := golastic.DocumentOf[book](ctx).Get("2"); !errors.Is(err, golastic.ErrNotFound) {
+		t.Errorf("unexpected error: expected %s, got %v", golastic.ErrNotFound, err)
+	}
+}
+
+func TestTypedSearch(t *testing.T) {
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"hits":{"total":{"value":2,"relation":"eq"},"hits":[
+			{"_id":"1","_score":2,"_source":{"title":"Foo"}},
+			{"_id":"2","_score":1,"_source":{"title":"Bar"}}
+		]}}`))
+	})
+
+	res, err := golastic.SearchOf[book](ctx).
+		WithExplain(false).
+		MultiMatchQuery("foo", []golastic.Field{{Name: "title"}}, golastic.SearchPagination{Size: 10}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := []book{{ID: "1", Title: "Foo", Score: 2}, {ID: "2", Title: "Bar", Score: 1}}
+	if len(res.Documents) != len(exp) {
+		t.Fatalf("unexpected documents: expected %v, got %v", exp, res.Documents)
+	}
+	for i, b := range res.Documents {
+		if b != exp[i] {
+			t.Errorf("unexpected document %d: expected %v, got %v", i, exp[i], b)
+		}
+	}
+	if res.TotalHits() != 2 {
+		t.Errorf("unexpected total hits: expected 2, got %d", res.TotalHits())
+	}
+}
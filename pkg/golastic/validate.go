@@ -0,0 +1,99 @@
+// This file regroups all entities and methods to interact with
+// Elasticseach Validate API.
+
+package golastic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ValidateQuery checks whether the given query is valid without executing it.
+// The result holds the query as rewritten by Elasticsearch into a Lucene query.
+//
+// An invalid query is not an error: a non-nil error is returned only if
+// the validation could not be performed.
+func (api *SearchAPI) ValidateQuery(q SearchQuery) (_ *ValidationResult, err error) {
+	c := api.instruments.start("validate_query", api.index)
+	defer func() { c.end(err) }()
+
+	// The Validate API only accepts the query in the request body.
+	v := SearchQuery{Query: q.Query}
+
+	res, err := api.client.Indices.ValidateQuery(
+		api.client.Indices.ValidateQuery.WithContext(c.ctx),
+		api.client.Indices.ValidateQuery.WithIndex(api.index),
+		api.client.Indices.ValidateQuery.WithBody(v.Reader()),
+		api.client.Indices.ValidateQuery.WithExplain(true),
+		api.client.Indices.ValidateQuery.WithRewrite(true),
+	)
+	c.response(res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to validate query: %s", ErrUnavailable, err)
+	}
+
+	defer res.Body.Close()
+	if err := readErrorResponse(res); err != nil {
+		return nil, err
+	}
+
+	var r ValidationResult
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// ValidationResult is the result of validating a query in Elasticsearch.
+type ValidationResult struct {
+	Valid        bool                    `json:"valid"`
+	Error        string                  `json:"error,omitempty"`
+	Explanations []ValidationExplanation `json:"explanations,omitempty"`
+}
+
+// ValidationExplanation is the validation outcome of a query for one index.
+type ValidationExplanation struct {
+	Index       string `json:"index"`
+	Valid       bool   `json:"valid"`
+	Explanation string `json:"explanation,omitempty"` // The rewritten Lucene query.
+	Error       string `json:"error,omitempty"`
+}
+
+// Rewritten conveniently returns the Lucene query rewritten by Elasticsearch.
+// Distinct rewrites across indices are separated by a new line.
+func (r *ValidationResult) Rewritten() string {
+	rewrites := make([]string, 0, len(r.Explanations))
+	seen := map[string]bool{}
+	for _, e := range r.Explanations {
+		if e.Explanation == "" || seen[e.Explanation] {
+			continue
+		}
+		seen[e.Explanation] = true
+		rewrites = append(rewrites, e.Explanation)
+	}
+	return strings.Join(rewrites, "\n")
+}
+
+// Err conveniently returns a non-nil error wrapping ErrBadRequest
+// if the query is invalid.
+func (r *ValidationResult) Err() error {
+	if r.Valid {
+		return nil
+	}
+
+	reasons := []string{}
+	if r.Error != "" {
+		reasons = append(reasons, r.Error)
+	}
+	for _, e := range r.Explanations {
+		if e.Error != "" {
+			reasons = append(reasons, e.Error)
+		}
+	}
+	if len(reasons) == 0 {
+		return fmt.Errorf("%w: invalid query", ErrBadRequest)
+	}
+	return fmt.Errorf("%w: invalid query: %s", ErrBadRequest, strings.Join(reasons, "; "))
+}
@@ -0,0 +1,54 @@
+package golastic_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestValidateQuery(t *testing.T) {
+	var uri string
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		uri = r.URL.RequestURI()
+		w.Write([]byte(`{"valid":true,"explanations":[
+			{"index":"books","valid":true,"explanation":"+(title:foo)^10.0 | abstract:foo"}
+		]}`))
+	})
+
+	res, err := golastic.Search(ctx).ValidateQuery(golastic.NewMultiMatchQuery("foo", []golastic.Field{
+		{Name: "title", Weight: 10},
+		{Name: "abstract"},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if exp := "/books/_validate/query?explain=true&rewrite=true"; uri != exp {
+		t.Errorf("unexpected request uri: expected %s, got %s", exp, uri)
+	}
+	if err := res.Err(); err != nil {
+		t.Errorf("unexpected validation error: %s", err)
+	}
+	if exp := "+(title:foo)^10.0 | abstract:foo"; res.Rewritten() != exp {
+		t.Errorf("unexpected rewritten query: expected %s, got %s", exp, res.Rewritten())
+	}
+}
+
+func TestValidateQueryInvalid(t *testing.T) {
+	ctx := newTestContext(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"valid":false,"explanations":[
+			{"index":"books","valid":false,"error":"failed to create query"}
+		]}`))
+	})
+
+	res, err := golastic.Search(ctx).ValidateQuery(golastic.NewMatchAllQuery())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := res.Err(); !errors.Is(err, golastic.ErrBadRequest) {
+		t.Errorf("expected validation error to be ErrBadRequest, got %v", err)
+	}
+}
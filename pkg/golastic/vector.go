@@ -0,0 +1,66 @@
+// This file regroups the entities used to rank documents by the similarity
+// of a dense_vector field with a query vector.
+
+package golastic
+
+import "fmt"
+
+// Vector similarity functions available in script_score queries.
+const (
+	// CosineSimilarity scores documents by the cosine of the angle between
+	// their vector and the query vector, shifted to be positive.
+	CosineSimilarity = "cosine"
+
+	// DotProduct scores documents by the dot product of their vector and
+	// the query vector, squashed by a sigmoid to be positive.
+	DotProduct = "dot_product"
+)
+
+// ScriptScoreQuery is the query for computing the score of the documents
+// retrieved by a query with a script.
+type ScriptScoreQuery struct {
+	Query    *Query  `json:"query"`
+	Script   Script  `json:"script"`
+	MinScore float64 `json:"min_score,omitempty"`
+	Boost    float64 `json:"boost,omitempty"`
+}
+
+// NewVectorSimilarityQuery returns a configured SearchQuery scoring
+// the documents retrieved by q by the similarity of their dense_vector
+// field with the given vector. Similarity is either CosineSimilarity
+// or DotProduct.
+//
+// All documents retrieved by q must have a value for the field. It is
+// advised to filter q with an ExistsQuery on the field.
+func NewVectorSimilarityQuery(q SearchQuery, field string, vector []float32, similarity string) SearchQuery {
+	var source string
+	switch similarity {
+	case DotProduct:
+		source = fmt.Sprintf(
+			"double value = dotProduct(params.query_vector, '%s'); return sigmoid(1, Math.E, -value);",
+			field,
+		)
+	default:
+		source = fmt.Sprintf("cosineSimilarity(params.query_vector, '%s') + 1.0", field)
+	}
+
+	inner := q.Query
+	sq := SearchQuery{Fields: q.Fields}
+	sq.Query.ScriptScore = &ScriptScoreQuery{
+		Query: &inner,
+		Script: Script{
+			Source: source,
+			Params: map[string]interface{}{"query_vector": vector},
+		},
+	}
+	return sq
+}
+
+// DenseVectorMapping returns the given index mapping extended with
+// a dense_vector field of the given dimensions.
+func DenseVectorMapping(mapping, field string, dims int) (string, error) {
+	return extendMapping(mapping, field, map[string]interface{}{
+		"type": "dense_vector",
+		"dims": dims,
+	})
+}
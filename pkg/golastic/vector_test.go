@@ -0,0 +1,32 @@
+package golastic_test
+
+import (
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/golastic"
+)
+
+func TestMarshalingVectorSimilarity(t *testing.T) {
+	q := golastic.SearchQuery{}
+	q.Query.Exists = &golastic.ExistsQuery{Field: "embedding"}
+
+	got := golastic.NewVectorSimilarityQuery(q, "embedding", []float32{0.5, -1}, golastic.CosineSimilarity).String()
+	exp := `{"query":{"script_score":{"query":{"exists":{"field":"embedding"}},"script":{` +
+		`"source":"cosineSimilarity(params.query_vector, 'embedding') + 1.0",` +
+		`"params":{"query_vector":[0.5,-1]}}}}}`
+
+	if got != exp {
+		t.Errorf("unexpected vector similarity marshaling output: expected %s, got %s", exp, got)
+	}
+}
+
+func TestDenseVectorMapping(t *testing.T) {
+	got, err := golastic.DenseVectorMapping(`{"mappings":{"properties":{}}}`, "embedding", 64)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if exp := `{"mappings":{"properties":{"embedding":{"dims":64,"type":"dense_vector"}}}}`; got != exp {
+		t.Errorf("unexpected mapping: expected %s, got %s", exp, got)
+	}
+}
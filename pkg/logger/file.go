@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 const (
@@ -14,26 +15,80 @@ const (
 	defaultPerm = fs.ModePerm
 )
 
+// openFiles holds the files opened by the file loggers, to close them
+// on shutdown.
+var openFiles = struct {
+	sync.Mutex
+	files []*fileLogger
+}{}
+
+// fileLogger writes to a file, opened on the first write and kept
+// open until Close is called.
 type fileLogger struct {
 	filename string
+
+	mu   sync.Mutex
+	file *os.File
 }
 
-func (fl fileLogger) Write(b []byte) (int, error) {
-	f, err := openFileAll(fl.filename, defaultFlag, defaultPerm)
-	if err != nil {
-		return 0, fmt.Errorf("write error: could not open file %s: %w", fl.filename, err)
+func (fl *fileLogger) Write(b []byte) (int, error) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	if fl.file == nil {
+		f, err := openFileAll(fl.filename, defaultFlag, defaultPerm)
+		if err != nil {
+			return 0, fmt.Errorf("write error: could not open file %s: %w", fl.filename, err)
+		}
+		fl.file = f
+	}
+	return fl.file.Write(b)
+}
+
+func (fl *fileLogger) close() error {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	if fl.file == nil {
+		return nil
 	}
-	return f.Write(b)
+	err := fl.file.Close()
+	fl.file = nil
+	return err
 }
 
 func File(filename, prefix string, flag int) *log.Logger {
-	return log.New(fileLogger{filename: filename}, prefix, flag)
+	fl := &fileLogger{filename: filename}
+
+	openFiles.Lock()
+	openFiles.files = append(openFiles.files, fl)
+	openFiles.Unlock()
+
+	return log.New(fl, prefix, flag)
 }
 
 func DefaultFile(filename string) *log.Logger {
 	return File(filename, log.Default().Prefix(), log.Default().Flags())
 }
 
+// Close closes the files of the loggers returned by File and DefaultFile.
+// A logger writing after Close opens its file again.
+func Close() error {
+	openFiles.Lock()
+	defer openFiles.Unlock()
+
+	var errs []error
+	for _, fl := range openFiles.files {
+		if err := fl.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("could not close log files: %v", errs)
+	}
+	return nil
+}
+
 // openFileAll, like os.OpenFile, opens a file creating it if necessary.
 // Unlike os.OpenFile, it also creates the missing parent directories
 // using os.MkdirAll.
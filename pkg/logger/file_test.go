@@ -41,6 +41,9 @@ func testWriteString(t *testing.T, w io.Writer, s string) {
 // Helpers
 
 func mustRemoveTmpDir() {
+	if err := logger.Close(); err != nil {
+		log.Panicf("failed to close log files: %s", err)
+	}
 	if err := os.RemoveAll(tmpDir); err != nil {
 		log.Panicf("failed to remove dir %s", tmpDir)
 	}
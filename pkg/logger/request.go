@@ -3,34 +3,56 @@ package logger
 import (
 	"log"
 	"net/http"
+
+	"github.com/moreirathomas/golastic/pkg/requestid"
 )
 
-// responseWriter is a wrapper around http.ResponseWriter that provides
+// ResponseWriter is a wrapper around http.ResponseWriter that provides
 // extra information on the request.
-type responseWriter struct {
+type ResponseWriter struct {
 	http.ResponseWriter
 	status int // Records the status code of the request.
 }
 
-func (rw *responseWriter) WriteHeader(statusCode int) {
+// NewResponseWriter returns a ResponseWriter wrapping w.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+	}
+}
+
+// Status returns the status code written, http.StatusOK by default.
+func (rw *ResponseWriter) Status() int {
+	return rw.status
+}
+
+func (rw *ResponseWriter) WriteHeader(statusCode int) {
 	rw.status = statusCode
 	rw.ResponseWriter.WriteHeader(statusCode)
 }
 
-// RequestLogger adds logging to the given http.Handler.
+// requestIDPrefix returns the ID of the request as a log prefix,
+// e.g. "[abc123] ", or an empty string if it has none.
+func requestIDPrefix(r *http.Request) string {
+	if id := requestid.FromContext(r.Context()); id != "" {
+		return "[" + id + "] "
+	}
+	return ""
+}
+
+// RequestLogger adds logging to the given http.Handler. The ID of the
+// request is logged if set by requestid.Middleware beforehand.
 func RequestLogger(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rw := &responseWriter{
-			ResponseWriter: w,
-			status:         http.StatusOK,
-		}
+		rw := NewResponseWriter(w)
 
 		h.ServeHTTP(rw, r)
 
-		c := statusColor(rw.status)
+		c := statusColor(rw.Status())
 		log.Printf(
-			"%s %s -> %s %s",
-			r.Method, r.URL.String(), c(rw.status), c(http.StatusText(rw.status)),
+			"%s%s %s -> %s %s",
+			requestIDPrefix(r), r.Method, r.URL.String(), c(rw.Status()), c(http.StatusText(rw.Status())),
 		)
 	})
 }
@@ -0,0 +1,70 @@
+// Package requestid identifies the requests served, so that their logs
+// and the calls they perform can be tied back to them.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header is the header carrying the ID of a request, and echoing it
+// in the response.
+const Header = "X-Request-ID"
+
+// maxLength is the maximum length of an ID accepted from a client.
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the given request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New returns a new random request ID.
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// The system random generator is not expected to fail.
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Middleware stores the ID of each request in its context and echoes it in
+// the response. The ID is read from the request header if valid, generated
+// otherwise.
+func Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = New()
+		}
+
+		w.Header().Set(Header, id)
+		h.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// valid reports whether an ID received from a client can be used as is.
+// It must be made of printable ASCII characters without spaces, so that
+// it is safely logged and forwarded.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
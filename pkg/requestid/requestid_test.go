@@ -0,0 +1,49 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/moreirathomas/golastic/pkg/requestid"
+)
+
+func TestMiddleware(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		generate bool
+	}{
+		{name: "accept", header: "abc-123"},
+		{name: "generate if missing", generate: true},
+		{name: "generate if invalid", header: "abc\n123", generate: true},
+		{name: "generate if too long", header: strings.Repeat("a", 129), generate: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			h := requestid.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = requestid.FromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				r.Header.Set(requestid.Header, tc.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if tc.generate && (got == "" || got == tc.header) {
+				t.Errorf("expected a generated ID, got %q", got)
+			}
+			if !tc.generate && got != tc.header {
+				t.Errorf("unexpected ID: expected %q, got %q", tc.header, got)
+			}
+			if echo := w.Header().Get(requestid.Header); echo != got {
+				t.Errorf("unexpected echoed ID: expected %q, got %q", got, echo)
+			}
+		})
+	}
+}